@@ -0,0 +1,311 @@
+package builder
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Tabler 自定义struct对应的表名，未实现时使用类型名经DefaultMapper转换
+type Tabler interface {
+	TableName() string
+}
+
+// 表结构变更类型
+const (
+	ChangeCreateTable = "create_table"
+	ChangeAddColumn   = "add_column"
+	ChangeAddIndex    = "add_index"
+)
+
+// SchemaChange AutoMigrate需要执行的一项变更
+type SchemaChange struct {
+	Table  string
+	Action string
+	// Name 字段或索引名，创建表时为空
+	Name string
+	SQL  []string
+}
+
+// String 返回变更的sql
+func (c SchemaChange) String() string {
+	return strings.Join(c.SQL, ";\n") + ";"
+}
+
+// AutoMigrate 按struct定义创建缺少的表，添加缺少的字段及索引，不会删除或修改已有字段；
+// 字段声明在sqlxb tag中，如:
+//
+//	ID    int64   `db:"id"`                                        // id或primary为主键，整数类型时自增
+//	Name  string  `db:"name" sqlxb:"size:45,index"`
+//	Email string  `db:"email" sqlxb:"unique"`
+//	Bio   *string `db:"bio" sqlxb:"type:text"`                     // 指针及sql.Null*类型可为空
+//	Score float64 `db:"score" sqlxb:"type:decimal,size:10,scale:2,default:0"`
+//	A, B  int     `sqlxb:"index:idx_a_b"`                         // 同名索引为联合索引
+func AutoMigrate(db *sqlx.DB, models ...interface{}) error {
+	changes, err := AutoMigrateDiff(db, models...)
+	if err != nil {
+		return err
+	}
+	s := Schema(db)
+	for _, c := range changes {
+		if err := s.exec(c.SQL); err != nil {
+			return fmt.Errorf("%s %s %s: %w", c.Action, c.Table, c.Name, err)
+		}
+	}
+	return nil
+}
+
+// AutoMigrateDiff 返回AutoMigrate将要执行的变更，不修改数据库
+func AutoMigrateDiff(db *sqlx.DB, models ...interface{}) ([]SchemaChange, error) {
+	s := Schema(db)
+	var changes []SchemaChange
+	for _, model := range models {
+		t, err := s.tableFromStruct(model)
+		if err != nil {
+			return nil, err
+		}
+		c, err := s.diff(t)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c...)
+	}
+	return changes, nil
+}
+
+// diff 比较表定义与数据库中的表结构
+func (s *SchemaBuilder) diff(t *Table) ([]SchemaChange, error) {
+	exists, err := s.HasTable(t.name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return []SchemaChange{{Table: t.name, Action: ChangeCreateTable, SQL: t.createSQL(false)}}, nil
+	}
+	columns, err := s.Columns(t.name)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := s.Indexes(t.name)
+	if err != nil {
+		return nil, err
+	}
+	var changes []SchemaChange
+	for _, c := range t.columns {
+		if containsFold(columns, c.name) {
+			continue
+		}
+		// 已有数据的表无法添加没有默认值的非空字段，使用零值作为默认值，无合适零值时允许为空；
+		// mysql 8.0.13之前TEXT等类型不支持默认值
+		if !c.hasDefault && !c.nullable {
+			switch c.kind {
+			case ColumnString:
+				c.Default("")
+			case ColumnText:
+				if t.dialect == DialectMySQL {
+					c.nullable = true
+				} else {
+					c.Default("")
+				}
+			case ColumnBoolean:
+				c.Default(false)
+			case ColumnSmallInteger, ColumnInteger, ColumnBigInteger, ColumnFloat, ColumnDouble, ColumnDecimal:
+				c.Default(0)
+			default:
+				c.nullable = true
+			}
+		}
+		alter := &Table{name: t.name, dialect: t.dialect, columns: []*Column{c}}
+		stmts, err := alter.alterSQL()
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, SchemaChange{Table: t.name, Action: ChangeAddColumn, Name: c.name, SQL: stmts})
+	}
+	for _, idx := range t.indexes {
+		if containsFold(indexes, idx.name) {
+			continue
+		}
+		changes = append(changes, SchemaChange{Table: t.name, Action: ChangeAddIndex, Name: idx.name, SQL: []string{t.indexSQL(idx, false)}})
+	}
+	return changes, nil
+}
+
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	bytesType = reflect.TypeOf([]byte(nil))
+)
+
+// tableFromStruct 读取struct字段生成表定义
+func (s *SchemaBuilder) tableFromStruct(model interface{}) (*Table, error) {
+	rt := reflect.TypeOf(model)
+	for rt != nil && rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("auto migrate model must be a struct, got %T", model)
+	}
+	name := DefaultMapper(rt.Name())
+	if tabler, ok := reflect.New(rt).Interface().(Tabler); ok {
+		name = tabler.TableName()
+	}
+	t := &Table{name: name, dialect: s.dialect}
+	indexes := make(map[string]*tableIndex)
+	var primary []*Column
+	for _, f := range structFields(rt) {
+		if isRelation(f.tags) {
+			continue
+		}
+		kind, nullable := columnKind(f.field.Type)
+		if v := f.tags["type"]; v != "" {
+			kind = v
+		}
+		if kind == "" && isStructDest(reflect.New(indirectType(f.field.Type)).Interface()) {
+			// 关联查询映射的嵌套struct，实现sql.Scanner的自定义类型需声明type
+			continue
+		}
+		if kind == "" {
+			return nil, fmt.Errorf("unsupported type %s of field %s.%s, declare it with type tag", f.field.Type, rt.Name(), f.field.Name)
+		}
+		c := t.Column(f.column, kind)
+		c.size, _ = strconv.Atoi(f.tags["size"])
+		c.precision, c.scale = c.size, 0
+		if v := f.tags["scale"]; v != "" {
+			c.scale, _ = strconv.Atoi(v)
+		}
+		if _, ok := f.tags["nullable"]; ok || nullable {
+			c.nullable = true
+		}
+		if _, ok := f.tags["soft_delete"]; ok {
+			c.nullable = true
+		}
+		if _, ok := f.tags["unsigned"]; ok || isUnsigned(f.field.Type) {
+			c.unsigned = true
+		}
+		if v, ok := f.tags["default"]; ok {
+			// 字符串类型的默认值按字面量转义，其他类型为sql表达式
+			if c.kind == ColumnString || c.kind == ColumnText {
+				c.Default(v)
+			} else {
+				c.DefaultRaw(v)
+			}
+		}
+		if _, ok := f.tags["primary"]; ok {
+			primary = append(primary, c)
+		}
+		for _, key := range []string{"index", "unique"} {
+			v, ok := f.tags[key]
+			if !ok {
+				continue
+			}
+			if v == "" {
+				v = t.indexName(If(key == "unique", "uniq", "idx").(string), []string{f.column})
+			}
+			idx := indexes[v]
+			if idx == nil {
+				idx = &tableIndex{name: v, unique: key == "unique"}
+				indexes[v] = idx
+				t.indexes = append(t.indexes, idx)
+			}
+			idx.columns = append(idx.columns, f.column)
+		}
+	}
+	if len(primary) == 0 {
+		for _, c := range t.columns {
+			if c.name == "id" {
+				primary = append(primary, c)
+			}
+		}
+	}
+	switch len(primary) {
+	case 0:
+	case 1:
+		c := primary[0]
+		c.primary, c.nullable = true, false
+		// 整数主键自增
+		switch c.kind {
+		case ColumnInteger, ColumnSmallInteger:
+			c.kind, c.autoIncrement = ColumnIncrements, true
+		case ColumnBigInteger:
+			c.kind, c.autoIncrement = ColumnBigIncrements, true
+		}
+	default:
+		var names []string
+		for _, c := range primary {
+			c.nullable = false
+			names = append(names, c.name)
+		}
+		t.PrimaryKey(names...)
+	}
+	sort.SliceStable(t.indexes, func(i, j int) bool { return t.indexes[i].name < t.indexes[j].name })
+	return t, nil
+}
+
+func indirectType(rt reflect.Type) reflect.Type {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	return rt
+}
+
+// isUnsigned 判断是否为无符号整数类型
+func isUnsigned(rt reflect.Type) bool {
+	switch indirectType(rt).Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// columnKind 按Go类型返回字段类型，指针及sql.Null*类型可为空
+func columnKind(rt reflect.Type) (string, bool) {
+	nullable := false
+	if rt.Kind() == reflect.Ptr {
+		rt, nullable = rt.Elem(), true
+	}
+	if rt == timeType {
+		return ColumnDateTime, nullable
+	}
+	if rt == bytesType {
+		return ColumnBinary, nullable
+	}
+	// sql.NullString等，取Valid之外的字段类型
+	if rt.Kind() == reflect.Struct && reflect.PtrTo(rt).Implements(scannerType) {
+		if _, ok := rt.FieldByName("Valid"); ok && rt.NumField() == 2 {
+			kind, _ := columnKind(rt.Field(0).Type)
+			return kind, true
+		}
+		return "", nullable
+	}
+	switch rt.Kind() {
+	case reflect.String:
+		return ColumnString, nullable
+	case reflect.Bool:
+		return ColumnBoolean, nullable
+	case reflect.Int8, reflect.Int16, reflect.Uint8, reflect.Uint16:
+		return ColumnSmallInteger, nullable
+	case reflect.Int, reflect.Int32, reflect.Uint32:
+		return ColumnInteger, nullable
+	case reflect.Int64, reflect.Uint, reflect.Uint64:
+		return ColumnBigInteger, nullable
+	case reflect.Float32:
+		return ColumnFloat, nullable
+	case reflect.Float64:
+		return ColumnDouble, nullable
+	}
+	return "", nullable
+}
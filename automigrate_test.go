@@ -0,0 +1,136 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+type migratePerson struct {
+	ID        int64          `db:"id"`
+	Name      string         `db:"name" sqlxb:"size:45,index"`
+	Email     sql.NullString `db:"email" sqlxb:"unique"`
+	Score     float64        `db:"score" sqlxb:"type:decimal,size:8,scale:2,default:0"`
+	Active    bool           `db:"active" sqlxb:"default:1"`
+	A         int            `db:"a" sqlxb:"index:idx_migrate_a_b"`
+	B         int            `db:"b" sqlxb:"index:idx_migrate_a_b"`
+	DeletedAt *time.Time     `db:"deleted_at" sqlxb:"soft_delete"`
+	Orders    []migrateOrder `db:"-" sqlxb:"has_many,foreign_key:person_id"`
+}
+
+func (migratePerson) TableName() string {
+	return "migrate_person"
+}
+
+type migrateOrder struct {
+	PersonID int64 `db:"person_id" sqlxb:"primary"`
+	No       int   `db:"no" sqlxb:"primary"`
+}
+
+type testJSON struct {
+	Data map[string]interface{}
+}
+
+func (j *testJSON) Scan(src interface{}) error {
+	return nil
+}
+
+func TestAutoMigrate(t *testing.T) {
+	db := newTestDB(t, "CREATE TABLE migrate_person (id INTEGER PRIMARY KEY, name VARCHAR(45) NOT NULL)",
+		"INSERT INTO migrate_person (name) VALUES ('tom')")
+	changes, err := AutoMigrateDiff(db, &migratePerson{}, migrateOrder{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, c := range changes {
+		actions = append(actions, c.Action+" "+c.Table+" "+c.Name)
+	}
+	expected := []string{
+		"add_column migrate_person email", "add_column migrate_person score", "add_column migrate_person active",
+		"add_column migrate_person a", "add_column migrate_person b", "add_column migrate_person deleted_at",
+		"add_index migrate_person idx_migrate_a_b", "add_index migrate_person idx_migrate_person_name",
+		"add_index migrate_person uniq_migrate_person_email", "create_table migrate_order ",
+	}
+	if strings.Join(actions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected diff:\n%s", strings.Join(actions, "\n"))
+	}
+	if s := changes[1].String(); s != `ALTER TABLE "migrate_person" ADD COLUMN "score" DECIMAL(8, 2) NOT NULL DEFAULT 0;` {
+		t.Errorf("unexpected sql %s", s)
+	}
+	if s := changes[len(changes)-1].String(); !strings.Contains(s, `PRIMARY KEY ("person_id", "no")`) {
+		t.Errorf("expected composite primary key: %s", s)
+	}
+
+	if err := AutoMigrate(db, &migratePerson{}, migrateOrder{}); err != nil {
+		t.Fatal(err)
+	}
+	var p migratePerson
+	if err := NewBuilder(db).Table("migrate_person").Fields("id", "name", "email", "score", "active", "a", "b", "deleted_at").One(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "tom" || !p.Active || p.Email.Valid {
+		t.Errorf("unexpected existing row %+v", p)
+	}
+	if changes, err := AutoMigrateDiff(db, &migratePerson{}, &migrateOrder{}); err != nil || len(changes) != 0 {
+		t.Errorf("expected no changes, got %v %v", changes, err)
+	}
+	if _, err := AutoMigrateDiff(db, &struct{ M map[string]int }{}); err == nil {
+		t.Error("expected unsupported type error")
+	}
+	// 实现sql.Scanner的自定义类型需声明type，不能当作嵌套struct忽略
+	if _, err := AutoMigrateDiff(db, &struct{ Meta testJSON }{}); err == nil {
+		t.Error("expected error for scanner type without type tag")
+	}
+	if changes, err := AutoMigrateDiff(db, &struct {
+		Meta testJSON `db:"meta" sqlxb:"type:json"`
+	}{}); err != nil || len(changes) != 1 || !strings.Contains(changes[0].String(), `"meta" TEXT NOT NULL`) {
+		t.Errorf("unexpected changes %v %v", changes, err)
+	}
+}
+
+func TestAutoMigrateMySQL(t *testing.T) {
+	type migrateItem struct {
+		ID    uint64 `db:"id"`
+		Code  string `db:"code" sqlxb:"size:10,default:it's"`
+		Stock uint32 `db:"stock"`
+		Note  string `db:"note" sqlxb:"type:text"`
+	}
+	s := &SchemaBuilder{dialect: DialectMySQL}
+	tb, err := s.tableFromStruct(&migrateItem{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := tb.createSQL(false)[0]
+	for _, def := range []string{
+		"`id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY",
+		"`code` VARCHAR(10) NOT NULL DEFAULT 'it\\'s'",
+		"`stock` INT UNSIGNED NOT NULL",
+	} {
+		if !strings.Contains(stmt, def) {
+			t.Errorf("expected %s in:\n%s", def, stmt)
+		}
+	}
+
+	// 已有表新增TEXT字段时允许为空
+	db := newTestDB(t)
+	fake := func(ctx context.Context, stmt *Statement, next Handler) (*Result, error) {
+		names := []string{"id", "code", "stock"}
+		if strings.Contains(stmt.Query, "information_schema.tables") {
+			names = []string{"migrate_item"}
+		}
+		*(stmt.Dest.(*[]string)) = names
+		return &Result{Rows: int64(len(names))}, nil
+	}
+	s = NewBuilder(db).Intercept(fake).Schema()
+	s.dialect = DialectMySQL
+	changes, err := s.diff(tb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].String() != "ALTER TABLE `migrate_item` ADD COLUMN `note` TEXT NULL;" {
+		t.Errorf("unexpected changes %v", changes)
+	}
+}
@@ -5,15 +5,16 @@ import (
 	// _ "github.com/go-sql-driver/mysql"
 	//_ "github.com/mattn/go-sqlite3"
 
+	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
-// LogTemp 打印日志格式
-var LogTemp = "sql output:\nquery: %v\n args: %v\n"
-
 // DB sqlx.DB or sqlx.Tx
 type DB interface {
 	DriverName() string
@@ -29,6 +30,11 @@ type DB interface {
 	Exec(query string, args ...interface{}) (sql.Result, error)
 	Preparex(query string) (*sqlx.Stmt, error)
 	PrepareNamed(query string) (*sqlx.NamedStmt, error)
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error)
+	QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
 // Builder 返回sqlx的builder
@@ -37,16 +43,56 @@ type Builder struct {
 	tx    *sqlx.Tx
 	debug bool
 	query *Query
+	// trashed 软删除数据的查询范围
+	trashed int
+	// clock 时钟函数，为空时使用NowFunc
+	clock func() time.Time
+	// validate 写入前是否校验数据
+	validate bool
+	// 全局查询范围
+	withoutScopes    []string
+	withoutAllScopes bool
+	scopesApplied    bool
+	// ctx 执行sql时使用的context
+	ctx context.Context
+	// 多租户
+	tenant      interface{}
+	crossTenant bool
+	// preloads 预加载的关联
+	preloads []string
+	// 日志
+	logger        Logger
+	slowThreshold time.Duration
+	queryHooks    []QueryHook
+	interceptors  []Interceptor
+	// 读写分离
+	cluster    *Cluster
+	usePrimary bool
+	unsafe     bool
+	// 分片
+	shardKey    interface{}
+	hasShardKey bool
+	shard       *Shard
+	// qualifier 自动附加条件中限定字段的表名，为空时使用逻辑表名
+	qualifier string
 }
 
 // NewBuilder return new builder
 func NewBuilder(db *sqlx.DB) *Builder {
-	return &Builder{db: db, tx: nil, query: nil}
+	return &Builder{db: db, tx: nil, query: nil, validate: DefaultValidate}
+}
+
+// NewClusterBuilder 返回使用集群的builder，读操作路由到从库
+func NewClusterBuilder(c *Cluster) *Builder {
+	b := NewBuilder(c.Primary)
+	b.cluster = c
+	return b
 }
 
 // Unsafe Scan Destination Safety
 func (b *Builder) Unsafe() *Builder {
 	b.db = b.db.Unsafe()
+	b.unsafe = true
 	return b
 }
 
@@ -61,9 +107,26 @@ func (b *Builder) DB() DB {
 	if b.tx != nil {
 		return b.tx
 	}
+	if shard, _ := b.currentShard(); shard != nil {
+		return shard.DB
+	}
 	return b.db
 }
 
+// WithContext 设置执行sql时使用的context
+func (b *Builder) WithContext(ctx context.Context) *Builder {
+	b.ctx = ctx
+	return b
+}
+
+// context 返回执行sql时使用的context
+func (b *Builder) context() context.Context {
+	if b.ctx != nil {
+		return b.ctx
+	}
+	return context.Background()
+}
+
 // Debug 设置debug值
 func (b *Builder) Debug(v bool) *Builder {
 	b.debug = v
@@ -72,26 +135,73 @@ func (b *Builder) Debug(v bool) *Builder {
 
 // One 返回单条数据
 func (b *Builder) One(dest interface{}) error {
-	query, args, err := b.BuildQuery()
+	cp := b.withJoinFields(dest)
+	query, args, err := cp.BuildQuery()
+	if err != nil {
+		return err
+	}
+	if cp != b {
+		err = b.scanJoined(dest, query, args, true)
+	} else {
+		err = b._get(dest, query, args)
+	}
 	if err != nil {
 		return err
 	}
-	if b.debug {
-		log.Printf(LogTemp, query, args)
+	if err := b.preload(dest); err != nil {
+		return err
 	}
-	return b.DB().Get(dest, query, args...)
+	return callAfterFind(dest)
 }
 
 // All 返回多条数据
 func (b *Builder) All(dest interface{}) error {
-	query, args, err := b.BuildQuery()
+	if shards := b.scatter(); shards != nil {
+		return scatterAll(shards, dest)
+	}
+	cp := b.withJoinFields(dest)
+	query, args, err := cp.BuildQuery()
 	if err != nil {
 		return err
 	}
-	if b.debug {
-		log.Printf(LogTemp, query, args)
+	if cp != b {
+		err = b.scanJoined(dest, query, args, false)
+	} else {
+		err = b._select(dest, query, args)
 	}
-	return b.DB().Select(dest, query, args...)
+	if err != nil {
+		return err
+	}
+	if err := b.preload(dest); err != nil {
+		return err
+	}
+	return callAfterFind(dest)
+}
+
+// Count 返回符合条件的记录数
+func (b *Builder) Count() (int64, error) {
+	if shards := b.scatter(); shards != nil {
+		return scatterCount(shards)
+	}
+	cp := b.Copy()
+	cp.query.Order = ""
+	cp.query.Limit = 0
+	cp.query.Offset = 0
+	cp.query.Lock = ""
+	var total int64
+	if cp.query.Group == "" && !cp.query.Distinct {
+		cp.query.Fields = []string{"COUNT(*)"}
+		err := cp.One(&total)
+		return total, err
+	}
+	// 分组或去重时以子查询统计
+	query, args, err := cp.BuildQuery()
+	if err != nil {
+		return 0, err
+	}
+	query = fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS t", query)
+	err = b._get(&total, query, args)
+	return total, err
 }
 
 // Update 执行 UPDATE 语句
@@ -104,6 +214,66 @@ func (b *Builder) Insert(data interface{}) (sql.Result, error) {
 	return b._exec("INSERT", data)
 }
 
+// BatchInsert 批量执行 INSERT 语句，data为struct或map的切片
+func (b *Builder) BatchInsert(data interface{}) (sql.Result, error) {
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Slice {
+		return nil, errors.New("batch insert data must be a slice")
+	}
+	rows := make([]map[string]interface{}, 0, rv.Len())
+	items := make([]interface{}, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		item := rv.Index(i)
+		if item.Kind() == reflect.Interface {
+			item = item.Elem()
+		}
+		if item.Kind() == reflect.Struct {
+			if !item.CanAddr() {
+				ptr := reflect.New(item.Type())
+				ptr.Elem().Set(item)
+				item = ptr
+			} else {
+				item = item.Addr()
+			}
+		}
+		s := item.Interface()
+		if err := b.beforeWrite("INSERT", i, s); err != nil {
+			return nil, err
+		}
+		row := toMap(s)
+		b.touchTimestamps("INSERT", s, row)
+		rows = append(rows, row)
+		items = append(items, s)
+	}
+	query, args, err := b.BuildBatchInsert(rows)
+	if err != nil {
+		return nil, err
+	}
+	// 参数中的敏感字段无法按占位符前的字段名识别，按数据隐藏
+	var displayArgs []interface{}
+	redacted := make([]map[string]interface{}, len(rows))
+	hidden := false
+	for i, row := range rows {
+		redacted[i] = row
+		if r, ok := b.redactData(row); ok {
+			redacted[i], hidden = r, true
+		}
+	}
+	if hidden {
+		_, displayArgs, _ = b.BuildBatchInsert(redacted)
+	}
+	result, err := b._execSQL(query, args, query, displayArgs)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range items {
+		if err := callAfter("INSERT", s); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
 // InsertIgnore 执行 INSERT_IGNORE 语句
 func (b *Builder) InsertIgnore(data interface{}) (sql.Result, error) {
 	return b._exec("INSERT_IGNORE", data)
@@ -114,25 +284,89 @@ func (b *Builder) InsertOnDuplicateUpdate(data interface{}) (sql.Result, error)
 	return b._exec("INSERT_ON_DUPLICATE_UPDATE", data)
 }
 
-// Delete 执行 DELETE 语言
+// Delete 执行 DELETE 语言，模型启用软删除时改为更新软删除字段
 func (b *Builder) Delete() (sql.Result, error) {
-	return b._exec("DELETE", nil)
+	if column := b.softDeleteColumn(); column != "" {
+		// 软删除及更新时间字段不受Fields限制
+		cp := b.Copy()
+		cp.query.Fields = nil
+		data := map[string]interface{}{column: cp.now()}
+		cp.touchTimestamps("UPDATE", nil, data)
+		return cp._execData("UPDATE", data)
+	}
+	return b._execData("DELETE", nil)
 }
 
-// _exec 执行sql语句
+// Exec 执行原生sql，与其他语句一样经过拦截器、QueryHook及日志
+func (b *Builder) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return b._execSQL(query, args, query, nil)
+}
+
+// _exec 执行sql语句，依次调用钩子、校验及时间字段填充
 func (b *Builder) _exec(method string, s interface{}) (sql.Result, error) {
-	var err error
-	query, args, err := b.BuildExec(method, StructToMap(s))
+	if err := b.beforeWrite(method, 0, s); err != nil {
+		return nil, err
+	}
+	data := toMap(s)
+	b.touchTimestamps(method, s, data)
+	result, err := b._execData(method, data)
 	if err != nil {
 		return nil, err
 	}
-	if b.debug {
-		log.Printf(LogTemp, query, args)
+	if err = callAfter(method, s); err != nil {
+		return result, err
 	}
-	var result sql.Result
-	result, err = b.DB().Exec(query, args...)
+	return result, nil
+}
+
+// _execData 按方法生成并执行sql语句
+func (b *Builder) _execData(method string, data map[string]interface{}) (sql.Result, error) {
+	query, args, err := b.BuildExec(method, data)
 	if err != nil {
 		return nil, err
 	}
-	return result, nil
+	display := query
+	if redacted, ok := b.redactData(data); ok {
+		display, _, _ = b.BuildExec(method, redacted)
+	}
+	return b._execSQL(query, args, display, nil)
+}
+
+// _execSQL 执行sql语句，display、displayArgs为日志中显示的隐藏敏感字段后的语句及参数，
+// displayArgs为空时按占位符前的字段名隐藏
+func (b *Builder) _execSQL(query string, args []interface{}, display string, displayArgs []interface{}) (sql.Result, error) {
+	stmt := b.statement(StatementExec, query, args, nil)
+	stmt.display, stmt.displayArgs = display, displayArgs
+	result, err := b._run(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if result.Exec == nil {
+		return nil, errors.New("interceptor returned no result for exec")
+	}
+	return result.Exec, nil
+}
+
+// _get 执行查询并赋值单条数据
+func (b *Builder) _get(dest interface{}, query string, args []interface{}) error {
+	_, err := b._run(b.statement(StatementGet, query, args, dest))
+	return err
+}
+
+// _select 执行查询并赋值多条数据
+func (b *Builder) _select(dest interface{}, query string, args []interface{}) error {
+	_, err := b._run(b.statement(StatementSelect, query, args, dest))
+	return err
+}
+
+// _queryx 执行查询并返回rows
+func (b *Builder) _queryx(query string, args []interface{}) (*sqlx.Rows, error) {
+	result, err := b._run(b.statement(StatementQuery, query, args, nil))
+	if err != nil {
+		return nil, err
+	}
+	if result.Cursor == nil {
+		return nil, errors.New("interceptor returned no rows for query")
+	}
+	return result.Cursor, nil
 }
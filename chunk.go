@@ -0,0 +1,77 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Chunk 按id分批读取数据，见ChunkByID
+func (b *Builder) Chunk(size int, fn interface{}) error {
+	return b.ChunkByID("id", size, fn)
+}
+
+// ChunkByID 按column的范围分批读取数据并调用fn，fn的格式为 func([]T) error 或 func([]*T) error。
+// 使用 column > 上一批最大值 的方式翻页而不是OFFSET，迭代过程中的更新不会导致遗漏或重复；
+// 保留当前的查询条件，事务中使用时通过SetTx设置；按column升序读取，不能指定其他排序。
+// T为基本类型时(如 func([]int64) error)读取column本身的值
+func (b *Builder) ChunkByID(column string, size int, fn interface{}) error {
+	if size <= 0 {
+		return errors.New("chunk size must be greater than 0")
+	}
+	if order := strings.Join(strings.Fields(b.query.Order), " "); order != "" &&
+		!strings.EqualFold(order, column) && !strings.EqualFold(order, column+" ASC") {
+		return fmt.Errorf("chunk orders by %s, conflicting order: %s", column, b.query.Order)
+	}
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() != 1 || ft.In(0).Kind() != reflect.Slice ||
+		ft.NumOut() != 1 || ft.Out(0) != errorType {
+		return errors.New("chunk callback must be func([]T) error")
+	}
+	// 结构体中的字段名不带表名前缀
+	key := column[strings.LastIndex(column, ".")+1:]
+	scalar := !isStructDest(reflect.New(indirectType(ft.In(0).Elem())).Interface())
+	var last interface{}
+	for {
+		cp := b.Copy()
+		if last != nil {
+			// 原有条件整体加括号，避免其中的OR绕过翻页条件
+			if len(cp.query.Where) > 0 {
+				cp.query.Where = [][]interface{}{{"AND", cp.query.Where, []interface{}{}}}
+			}
+			cp.Where(column+" > ?", last)
+		}
+		cp.query.Order = column
+		cp.query.Limit = size
+		cp.query.Offset = 0
+		if scalar && len(cp.query.Fields) == 0 {
+			cp.query.Fields = []string{column}
+		}
+		batch := reflect.New(ft.In(0))
+		if err := cp.All(batch.Interface()); err != nil {
+			return err
+		}
+		n := batch.Elem().Len()
+		if n == 0 {
+			return nil
+		}
+		if out := fv.Call([]reflect.Value{batch.Elem()})[0]; !out.IsNil() {
+			return out.Interface().(error)
+		}
+		if n < size {
+			return nil
+		}
+		if scalar {
+			last = reflect.Indirect(batch.Elem().Index(n - 1)).Interface()
+			continue
+		}
+		items := collectStructs(batch)
+		f := fieldByColumn(items[len(items)-1], key)
+		if !f.IsValid() {
+			return fmt.Errorf("column %s not found in %s", key, items[0].Type().Name())
+		}
+		last = f.Interface()
+	}
+}
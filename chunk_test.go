@@ -0,0 +1,81 @@
+package builder
+
+import "testing"
+
+type testEvent struct {
+	ID   int64 `db:"id" json:"id"`
+	Done int64 `db:"done" json:"done"`
+}
+
+func TestChunk(t *testing.T) {
+	db := newTestDB(t, "CREATE TABLE event (id INTEGER PRIMARY KEY, done INTEGER)")
+	for i := 1; i <= 7; i++ {
+		if _, err := NewBuilder(db).Table("event").Insert(&testEvent{ID: int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tx := db.MustBegin()
+	defer tx.Rollback()
+	var sizes []int
+	// 迭代中更新数据不影响翻页
+	err := NewBuilder(db).SetTx(tx).Table("event").Where("done = ?", 0).Chunk(3, func(batch []*testEvent) error {
+		sizes = append(sizes, len(batch))
+		for _, e := range batch {
+			if _, err := NewBuilder(db).SetTx(tx).Table("event").Where("id = ?", e.ID).Update(map[string]interface{}{"done": 1}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sizes) != 3 || sizes[0] != 3 || sizes[2] != 1 {
+		t.Errorf("unexpected batches: %v", sizes)
+	}
+	n, err := NewBuilder(db).SetTx(tx).Table("event").Where("done = ?", 1).Count()
+	if err != nil || n != 7 {
+		t.Errorf("expected all events processed, got %d %v", n, err)
+	}
+}
+
+func TestChunkWithOr(t *testing.T) {
+	db := newTestDB(t, "CREATE TABLE event (id INTEGER PRIMARY KEY, done INTEGER)")
+	for i := 1; i <= 5; i++ {
+		if _, err := NewBuilder(db).Table("event").Insert(&testEvent{ID: int64(i), Done: int64(i % 2)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var ids []int64
+	err := NewBuilder(db).Table("event").Where("done = 0 OR done = 1").Chunk(2, func(batch []testEvent) error {
+		for _, e := range batch {
+			ids = append(ids, e.ID)
+		}
+		if len(ids) > 10 {
+			t.Fatal("chunk did not advance")
+		}
+		return nil
+	})
+	if err != nil || len(ids) != 5 {
+		t.Errorf("unexpected ids: %v %v", ids, err)
+	}
+	err = NewBuilder(db).Table("event").OrderBy("done DESC").Chunk(2, func(batch []testEvent) error { return nil })
+	if err == nil {
+		t.Error("expected error for conflicting order")
+	}
+}
+
+func TestChunkScalar(t *testing.T) {
+	db := newTestDB(t, "CREATE TABLE event (id INTEGER PRIMARY KEY, done INTEGER)",
+		"INSERT INTO event VALUES (1, 0), (2, 0), (3, 0), (4, 0), (5, 0)")
+	var ids []int64
+	calls := 0
+	err := NewBuilder(db).Table("event").Chunk(2, func(batch []int64) error {
+		calls++
+		ids = append(ids, batch...)
+		return nil
+	})
+	if err != nil || calls != 3 || len(ids) != 5 || ids[4] != 5 {
+		t.Errorf("unexpected chunks: %d %v %v", calls, ids, err)
+	}
+}
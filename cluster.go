@@ -0,0 +1,87 @@
+package builder
+
+import (
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Balancer 从从库中选择一个执行读操作
+type Balancer func(replicas []*sqlx.DB) *sqlx.DB
+
+// RoundRobin 轮询选择从库
+func RoundRobin() Balancer {
+	var n uint32
+	return func(replicas []*sqlx.DB) *sqlx.DB {
+		i := atomic.AddUint32(&n, 1) - 1
+		return replicas[int(i%uint32(len(replicas)))]
+	}
+}
+
+// LeastConnections 选择使用中连接数最少的从库
+func LeastConnections() Balancer {
+	return func(replicas []*sqlx.DB) *sqlx.DB {
+		db, min := replicas[0], replicas[0].Stats().InUse
+		for _, r := range replicas[1:] {
+			if n := r.Stats().InUse; n < min {
+				db, min = r, n
+			}
+		}
+		return db
+	}
+}
+
+// Cluster 一主多从的数据库集群，读操作路由到从库，写操作、事务及加锁查询使用主库
+type Cluster struct {
+	Primary  *sqlx.DB
+	Replicas []*sqlx.DB
+	balancer Balancer
+}
+
+// NewCluster 创建集群，默认轮询选择从库
+func NewCluster(primary *sqlx.DB, replicas ...*sqlx.DB) *Cluster {
+	return &Cluster{Primary: primary, Replicas: replicas, balancer: RoundRobin()}
+}
+
+// Balance 设置从库选择策略
+func (c *Cluster) Balance(f Balancer) *Cluster {
+	c.balancer = f
+	return c
+}
+
+// DriverName 返回主库的驱动名
+func (c *Cluster) DriverName() string {
+	return c.Primary.DriverName()
+}
+
+// replica 选择一个从库，没有从库时返回主库
+func (c *Cluster) replica() *sqlx.DB {
+	if len(c.Replicas) == 0 {
+		return c.Primary
+	}
+	return c.balancer(c.Replicas)
+}
+
+// UsePrimary 强制当前builder的读操作使用主库，用于写后立即读取
+func (b *Builder) UsePrimary() *Builder {
+	b.usePrimary = true
+	return b
+}
+
+// readDB 返回执行读操作的数据库，事务、加锁查询及非SELECT语句使用主库
+func (b *Builder) readDB(stmt *Statement) DB {
+	if b.tx != nil || b.cluster == nil || b.usePrimary || stmt.Operation != "select" {
+		return b.DB()
+	}
+	if b.query != nil && b.query.Lock != "" {
+		return b.DB()
+	}
+	if shard, _ := b.currentShard(); shard != nil {
+		return b.DB()
+	}
+	db := b.cluster.replica()
+	if b.unsafe {
+		return db.Unsafe()
+	}
+	return db
+}
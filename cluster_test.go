@@ -0,0 +1,49 @@
+package builder
+
+import "testing"
+
+func TestCluster(t *testing.T) {
+	schema := "CREATE TABLE fruit (id INTEGER PRIMARY KEY, name TEXT)"
+	primary := newTestDB(t, schema, "INSERT INTO fruit VALUES (1, 'primary')")
+	r1 := newTestDB(t, schema, "INSERT INTO fruit VALUES (1, 'r1')")
+	r2 := newTestDB(t, schema, "INSERT INTO fruit VALUES (1, 'r2')")
+	c := NewCluster(primary, r1, r2)
+
+	var names []string
+	for i := 0; i < 3; i++ {
+		var name string
+		if err := NewClusterBuilder(c).Table("fruit").Where("id = ?", 1).Value("name", &name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	if names[0] != "r1" || names[1] != "r2" || names[2] != "r1" {
+		t.Errorf("expected round robin replicas, got %v", names)
+	}
+
+	if _, err := NewClusterBuilder(c).Table("fruit").Fields("name").Insert(map[string]interface{}{"name": "new"}); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := NewClusterBuilder(c).Table("fruit").Count(); n != 1 {
+		t.Errorf("expected replica count 1, got %d", n)
+	}
+	if n, _ := NewClusterBuilder(c).UsePrimary().Table("fruit").Count(); n != 2 {
+		t.Errorf("expected primary count 2, got %d", n)
+	}
+
+	b := NewClusterBuilder(c).Table("fruit").LockForUpdate()
+	if db := b.readDB(b.statement(StatementGet, "SELECT 1", nil, nil)); db != primary {
+		t.Error("expected locking read to use primary")
+	}
+	tx := primary.MustBegin()
+	defer tx.Rollback()
+	var name string
+	if err := NewClusterBuilder(c).SetTx(tx).Table("fruit").Where("id = ?", 1).Value("name", &name); err != nil || name != "primary" {
+		t.Errorf("expected tx read from primary, got %s %v", name, err)
+	}
+
+	c.Balance(LeastConnections())
+	if db := c.replica(); db != r1 {
+		t.Error("expected least connections replica")
+	}
+}
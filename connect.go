@@ -1,10 +1,15 @@
 package builder
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -12,21 +17,145 @@ import (
 // Connect 获得数据库连接
 func Connect(opts ...Option) (*sqlx.DB, error) {
 	o := NewOptions(opts...)
-	if o.Driver == "mysql" {
-		// [username[:password]@][protocol[(address)]]/dbname[?param1=value1&...&paramN=valueN]
-		o.URI = fmt.Sprintf("%s?charset=%s&parseTime=%v", o.URI, o.Charset, o.ParseTime)
-	}
-	db, err := sqlx.Connect(o.Driver, o.URI)
+	db, err := open(o)
 	if err != nil {
 		return nil, err
 	}
+	if err = ping(db, o); err != nil {
+		db.Close()
+		return nil, err
+	}
 	// 配置连接池
 	db.SetMaxOpenConns(o.MaxClient)
-	db.SetMaxIdleConns(o.MaxClient)
+	db.SetMaxIdleConns(If(o.MaxIdle < 0, o.MaxClient, o.MaxIdle).(int))
 	db.SetConnMaxLifetime(o.MaxLifetime)
+	setConnMaxIdleTime(db, o.MaxIdleTime)
+	connectHooks.RLock()
+	for _, f := range connectHooks.fs {
+		f(o.Name, db)
+	}
+	connectHooks.RUnlock()
+	if o.HealthCheckInterval > 0 {
+		registerHealthChecker(o.Name, NewHealthChecker(db, o.HealthCheckInterval))
+	}
 	return db, nil
 }
 
+// open 创建连接池，设置了ConnInits时包装驱动以初始化每个物理连接
+func open(o Options) (*sqlx.DB, error) {
+	if len(o.ConnInits) == 0 {
+		return sqlx.Open(o.Driver, o.DSN())
+	}
+	tmp, err := sql.Open(o.Driver, o.DSN())
+	if err != nil {
+		return nil, err
+	}
+	drv := tmp.Driver()
+	tmp.Close()
+	var connector driver.Connector = dsnConnector{dsn: o.DSN(), driver: drv}
+	if dc, ok := drv.(driver.DriverContext); ok {
+		if connector, err = dc.OpenConnector(o.DSN()); err != nil {
+			return nil, err
+		}
+	}
+	return sqlx.NewDb(sql.OpenDB(initConnector{Connector: connector, inits: o.ConnInits}), o.Driver), nil
+}
+
+// minRetryInterval 重试的最小等待时间，避免间隔为0时空转
+const minRetryInterval = 10 * time.Millisecond
+
+// ping 检查连接，设置了RetryTimeout时按退避间隔重试直到超时
+func ping(db *sqlx.DB, o Options) error {
+	deadline := time.Now().Add(o.RetryTimeout)
+	interval := o.RetryInterval
+	if interval < minRetryInterval {
+		interval = minRetryInterval
+	}
+	for {
+		err := db.Ping()
+		if err == nil || o.RetryTimeout <= 0 {
+			return err
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("connect %s: retry timeout after %s: %w", o.Name, o.RetryTimeout, err)
+		}
+		if interval > remaining {
+			interval = remaining
+		}
+		time.Sleep(interval)
+		if interval *= 2; o.RetryMaxInterval > 0 && interval > o.RetryMaxInterval {
+			interval = o.RetryMaxInterval
+		}
+	}
+}
+
+// ConnInitFunc 新建物理连接时调用，返回错误则放弃该连接
+type ConnInitFunc func(ctx context.Context, conn driver.Conn) error
+
+// dsnConnector 不支持DriverContext的驱动使用dsn创建连接
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// initConnector 在新建的物理连接上执行初始化函数
+type initConnector struct {
+	driver.Connector
+	inits []ConnInitFunc
+}
+
+func (c initConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range c.inits {
+		if err := f(ctx, conn); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// execConn 在驱动连接上执行sql
+func execConn(ctx context.Context, conn driver.Conn, query string) error {
+	if e, ok := conn.(driver.ExecerContext); ok {
+		_, err := e.ExecContext(ctx, query, nil)
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+	stmt, err := conn.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(nil)
+	return err
+}
+
+var connectHooks = struct {
+	sync.RWMutex
+	fs []func(name string, db *sqlx.DB)
+}{}
+
+// OnConnect 注册Connect成功后的回调，用于监控连接池等
+func OnConnect(f func(name string, db *sqlx.DB)) {
+	connectHooks.Lock()
+	connectHooks.fs = append(connectHooks.fs, f)
+	connectHooks.Unlock()
+}
+
 // Max 最大值
 func Max(n ...int) int {
 	return sort.IntSlice(n)[0]
@@ -86,9 +215,16 @@ func LoadMapper(db *sqlx.DB, mapper func(name string) string) {
 // StructToMap struct转map
 func StructToMap(i interface{}) map[string]interface{} {
 	values := make(map[string]interface{})
+	if i == nil {
+		return values
+	}
 	iVal := reflect.ValueOf(i).Elem()
 	tp := iVal.Type()
 	for i := 0; i < iVal.NumField(); i++ {
+		// 跳过关联关系字段
+		if isRelation(parseTag(tp.Field(i).Tag.Get(TagName))) {
+			continue
+		}
 		tag := tp.Field(i).Tag.Get("json")
 		if len(tag) > 0 {
 			name := strings.Split(tag, ",")[0]
@@ -100,6 +236,18 @@ func StructToMap(i interface{}) map[string]interface{} {
 	return values
 }
 
+// toMap 将执行数据转为map，支持struct指针及map[string]interface{}
+func toMap(i interface{}) map[string]interface{} {
+	if m, ok := i.(map[string]interface{}); ok {
+		values := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			values[k] = v
+		}
+		return values
+	}
+	return StructToMap(i)
+}
+
 func indexOf(element string, data []string) int {
 	for k, v := range data {
 		if element == v {
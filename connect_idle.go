@@ -0,0 +1,15 @@
+//go:build go1.15
+// +build go1.15
+
+package builder
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// setConnMaxIdleTime 设置连接最大空闲时间
+func setConnMaxIdleTime(db *sqlx.DB, d time.Duration) {
+	db.SetConnMaxIdleTime(d)
+}
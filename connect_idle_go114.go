@@ -0,0 +1,13 @@
+//go:build !go1.15
+// +build !go1.15
+
+package builder
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// setConnMaxIdleTime Go 1.15之前database/sql不支持设置空闲时间，忽略
+func setConnMaxIdleTime(db *sqlx.DB, d time.Duration) {}
@@ -0,0 +1,140 @@
+package builder
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// param 连接地址参数，保持添加顺序
+type param struct {
+	key   string
+	value string
+}
+
+// DSN 按驱动生成连接地址，URI中已有的参数优先
+func (o Options) DSN() string {
+	switch o.Driver {
+	case "mysql":
+		return MySQLDSN(o)
+	case "postgres", "pgx":
+		return PostgresDSN(o)
+	case "sqlite3", "sqlite":
+		return SQLiteDSN(o)
+	}
+	return mergeParams(o.URI, extraParams(o.Params))
+}
+
+// MySQLDSN 生成mysql连接地址
+// [username[:password]@][protocol[(address)]]/dbname[?param1=value1&...&paramN=valueN]
+func MySQLDSN(o Options) string {
+	params := []param{
+		{"charset", o.Charset},
+		{"parseTime", strconv.FormatBool(o.ParseTime)},
+	}
+	if o.Loc != nil {
+		params = append(params, param{"loc", o.Loc.String()})
+	}
+	if o.Timeout > 0 {
+		params = append(params, param{"timeout", o.Timeout.String()})
+	}
+	if o.ReadTimeout > 0 {
+		params = append(params, param{"readTimeout", o.ReadTimeout.String()})
+	}
+	if o.WriteTimeout > 0 {
+		params = append(params, param{"writeTimeout", o.WriteTimeout.String()})
+	}
+	if o.TLS != "" {
+		params = append(params, param{"tls", o.TLS})
+	}
+	return mergeParams(o.URI, append(params, extraParams(o.Params)...))
+}
+
+// PostgresDSN 生成postgres连接地址，支持 postgres:// 及 key=value 两种格式
+func PostgresDSN(o Options) string {
+	var params []param
+	if o.SSLMode != "" {
+		params = append(params, param{"sslmode", o.SSLMode})
+	}
+	if o.SearchPath != "" {
+		params = append(params, param{"search_path", o.SearchPath})
+	}
+	if o.Timeout > 0 {
+		seconds := int64(o.Timeout.Seconds())
+		if seconds < 1 {
+			seconds = 1
+		}
+		params = append(params, param{"connect_timeout", strconv.FormatInt(seconds, 10)})
+	}
+	if o.Loc != nil {
+		params = append(params, param{"timezone", o.Loc.String()})
+	}
+	params = append(params, extraParams(o.Params)...)
+	if strings.Contains(o.URI, "://") || o.URI == "" {
+		return mergeParams(o.URI, params)
+	}
+	// key=value 格式
+	existing := make(map[string]bool)
+	for _, field := range strings.Fields(o.URI) {
+		if i := strings.Index(field, "="); i > 0 {
+			existing[field[:i]] = true
+		}
+	}
+	dsn := o.URI
+	for _, p := range params {
+		if existing[p.key] {
+			continue
+		}
+		value := p.value
+		if value == "" || strings.ContainsAny(value, ` '\`) {
+			value = "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+		}
+		dsn += " " + p.key + "=" + value
+	}
+	return dsn
+}
+
+// SQLiteDSN 生成sqlite连接地址，参数为mattn/go-sqlite3支持的 _journal_mode、_foreign_keys 等
+func SQLiteDSN(o Options) string {
+	return mergeParams(o.URI, append(extraParams(o.Pragmas), extraParams(o.Params)...))
+}
+
+// extraParams 按参数名排序返回
+func extraParams(values url.Values) []param {
+	var keys []string
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var result []param
+	for _, k := range keys {
+		result = append(result, param{k, values.Get(k)})
+	}
+	return result
+}
+
+// mergeParams 将参数合并到uri的查询字符串，uri中已有的参数保持不变
+func mergeParams(uri string, params []param) string {
+	base, query := uri, ""
+	if i := strings.Index(uri, "?"); i >= 0 {
+		base, query = uri[:i], uri[i+1:]
+	}
+	existing, _ := url.ParseQuery(query)
+	added := make(map[string]bool)
+	var parts []string
+	if query != "" {
+		parts = append(parts, query)
+	}
+	for _, p := range params {
+		if _, ok := existing[p.key]; ok || added[p.key] {
+			continue
+		}
+		added[p.key] = true
+		parts = append(parts, url.QueryEscape(p.key)+"="+url.QueryEscape(p.value))
+	}
+	if len(parts) == 0 {
+		return base
+	}
+	return base + "?" + strings.Join(parts, "&")
+}
@@ -0,0 +1,45 @@
+package builder
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDSN(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	cases := []struct {
+		opts []Option
+		dsn  string
+	}{
+		{[]Option{Driver("mysql"), URI("root@tcp(127.0.0.1:3306)/test")},
+			"root@tcp(127.0.0.1:3306)/test?charset=utf8mb4&parseTime=true"},
+		{[]Option{Driver("mysql"), URI("root@/test?charset=latin1"), Loc(loc), Timeout(5 * time.Second), TLS("skip-verify")},
+			"root@/test?charset=latin1&parseTime=true&loc=Asia%2FShanghai&timeout=5s&tls=skip-verify"},
+		{[]Option{Driver("postgres"), URI("postgres://u@localhost/db?sslmode=verify-full"), SSLMode("disable"), SearchPath("app,public")},
+			"postgres://u@localhost/db?sslmode=verify-full&search_path=app%2Cpublic"},
+		{[]Option{Driver("postgres"), URI("host=localhost dbname=db"), SSLMode("require"), Param("application_name", "my app")},
+			"host=localhost dbname=db sslmode=require application_name='my app'"},
+		{[]Option{URI("file:test.db?cache=shared"), WAL(), ForeignKeys(true), BusyTimeout(time.Second)},
+			"file:test.db?cache=shared&_busy_timeout=1000&_foreign_keys=1&_journal_mode=WAL"},
+	}
+	for _, c := range cases {
+		if dsn := NewOptions(c.opts...).DSN(); dsn != c.dsn {
+			t.Errorf("expected %s, got %s", c.dsn, dsn)
+		}
+	}
+}
+
+func TestConnectOptions(t *testing.T) {
+	db, err := Connect(ForeignKeys(true), MaxClient(4), MaxIdle(2), MaxIdleTime(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var fk int
+	if err := db.Get(&fk, "PRAGMA foreign_keys"); err != nil || fk != 1 {
+		t.Errorf("expected foreign_keys enabled, got %d %v", fk, err)
+	}
+	if n := db.Stats().MaxOpenConnections; n != 4 {
+		t.Errorf("expected 4 max open connections, got %d", n)
+	}
+}
@@ -18,25 +18,31 @@ func main() {
 	}
 	sqlxb.LoadMapper(db, sqlxb.DefaultMapper)
 
-	schemas := []string{
-		`
-		CREATE TABLE IF NOT EXISTS my_app.person (
-			id INT NOT NULL AUTO_INCREMENT,
-			name VARCHAR(45) NULL,
-			create_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			PRIMARY KEY (id));
-		`,
+	migrator := sqlxb.NewMigrator(db)
+	err = migrator.Add(&sqlxb.Migration{
+		Version: 1,
+		Name:    "create_person",
+		Up: func(b *sqlxb.Builder) error {
+			return b.Schema().CreateTableIfNotExists("person", func(t *sqlxb.Table) {
+				t.Increments("id")
+				t.String("name", 45).Nullable()
+				t.DateTime("create_at").Nullable().DefaultRaw("CURRENT_TIMESTAMP")
+			})
+		},
+		Down: func(b *sqlxb.Builder) error {
+			return b.Schema().DropTable("person")
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
 	}
-
-	for _, s := range schemas {
-		if _, err := db.Exec(s); err != nil {
-			log.Fatal(err)
-		}
+	if err := migrator.Up(); err != nil {
+		log.Fatal(err)
 	}
 
 	person := &ex.Person{Name: "test name 1"}
 
-	result, err := sqlxb.NewBuilder(db).Debug(true).Table("person").Insert(person)
+	result, err := sqlxb.NewBuilder(db).Debug(true).Validation(true).Table("person").Insert(person)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -5,4 +5,5 @@ go 1.13
 require (
 	github.com/antlabs/deepcopy v0.0.2
 	github.com/jmoiron/sqlx v1.2.0
+	github.com/mattn/go-sqlite3 v1.14.6
 )
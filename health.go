@@ -0,0 +1,140 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// errDBClosed database/sql连接池关闭后返回的错误，该错误未导出，通过关闭一个空连接池获取
+var errDBClosed = func() error {
+	db := sql.OpenDB(closedConnector{})
+	db.Close()
+	return db.Ping()
+}()
+
+type closedConnector struct{}
+
+func (closedConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, driver.ErrBadConn
+}
+
+func (closedConnector) Driver() driver.Driver {
+	return nil
+}
+
+// HealthStatus 健康检查状态
+type HealthStatus struct {
+	Healthy bool
+	// Err 最近一次检查的错误
+	Err error
+	// CheckedAt 最近一次检查的时间
+	CheckedAt time.Time
+	// Failures 连续失败次数
+	Failures int
+}
+
+// HealthChecker 后台定期ping数据库并记录状态
+type HealthChecker struct {
+	db       *sqlx.DB
+	interval time.Duration
+	mu       sync.RWMutex
+	status   HealthStatus
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// NewHealthChecker 立即检查一次，之后每隔interval在后台检查；调用Stop或连接池关闭后停止
+func NewHealthChecker(db *sqlx.DB, interval time.Duration) *HealthChecker {
+	h := &HealthChecker{db: db, interval: interval, stop: make(chan struct{})}
+	h.check()
+	go h.run()
+	return h
+}
+
+func (h *HealthChecker) run() {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			h.check()
+		case <-h.stop:
+			return
+		}
+	}
+}
+
+// check ping数据库，超时时间为检查间隔
+func (h *HealthChecker) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), h.interval)
+	err := h.db.PingContext(ctx)
+	cancel()
+	if err != nil && errors.Is(err, errDBClosed) {
+		h.Stop()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.status.Healthy = err == nil
+	h.status.Err = err
+	h.status.CheckedAt = time.Now()
+	if err != nil {
+		h.status.Failures++
+	} else {
+		h.status.Failures = 0
+	}
+}
+
+// Status 返回最近一次检查的状态
+func (h *HealthChecker) Status() HealthStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.status
+}
+
+// Healthy 最近一次检查是否成功
+func (h *HealthChecker) Healthy() bool {
+	return h.Status().Healthy
+}
+
+// Stop 停止后台检查
+func (h *HealthChecker) Stop() {
+	h.once.Do(func() { close(h.stop) })
+}
+
+var healthCheckers = struct {
+	sync.RWMutex
+	m map[string]*HealthChecker
+}{m: make(map[string]*HealthChecker)}
+
+// stopHealthCheckers 停止并移除全部Connect创建的健康检查
+func stopHealthCheckers() {
+	healthCheckers.Lock()
+	for name, h := range healthCheckers.m {
+		h.Stop()
+		delete(healthCheckers.m, name)
+	}
+	healthCheckers.Unlock()
+}
+
+// registerHealthChecker 按连接名称保存Connect创建的健康检查，停止同名的旧检查
+func registerHealthChecker(name string, h *HealthChecker) {
+	healthCheckers.Lock()
+	if old := healthCheckers.m[name]; old != nil {
+		old.Stop()
+	}
+	healthCheckers.m[name] = h
+	healthCheckers.Unlock()
+}
+
+// GetHealthChecker 返回Connect时通过HealthCheck选项创建的健康检查，未创建返回nil
+func GetHealthChecker(name string) *HealthChecker {
+	healthCheckers.RLock()
+	defer healthCheckers.RUnlock()
+	return healthCheckers.m[name]
+}
@@ -0,0 +1,93 @@
+package builder
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyDriver 前fails次连接失败
+type flakyDriver struct {
+	driver.Driver
+	fails int32
+}
+
+func (d *flakyDriver) Open(dsn string) (driver.Conn, error) {
+	if atomic.AddInt32(&d.fails, -1) >= 0 {
+		return nil, errors.New("database is starting up")
+	}
+	return d.Driver.Open(dsn)
+}
+
+var flaky = &flakyDriver{}
+
+func init() {
+	db, _ := sql.Open("sqlite3", ":memory:")
+	flaky.Driver = db.Driver()
+	db.Close()
+	sql.Register("sqlxb_flaky", flaky)
+}
+
+func TestConnectRetry(t *testing.T) {
+	atomic.StoreInt32(&flaky.fails, 2)
+	if _, err := Connect(Driver("sqlxb_flaky")); err == nil {
+		t.Error("expected connect error without retry")
+	}
+
+	atomic.StoreInt32(&flaky.fails, 2)
+	db, err := Connect(Driver("sqlxb_flaky"), Name("flaky"), Retry(time.Second), Backoff(time.Millisecond, 5*time.Millisecond),
+		InitSQL("PRAGMA foreign_keys = ON"), HealthCheck(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var fk int
+	if err := db.Get(&fk, "PRAGMA foreign_keys"); err != nil || fk != 1 {
+		t.Errorf("expected init sql executed, got %d %v", fk, err)
+	}
+	h := GetHealthChecker("flaky")
+	if h == nil || !h.Healthy() {
+		t.Fatal("expected healthy checker")
+	}
+	h.Stop()
+
+	atomic.StoreInt32(&flaky.fails, 1000)
+	start := time.Now()
+	if _, err := Connect(Driver("sqlxb_flaky"), Retry(20*time.Millisecond), Backoff(time.Millisecond, 5*time.Millisecond)); err == nil {
+		t.Error("expected retry timeout")
+	}
+	if time.Since(start) > time.Second {
+		t.Error("retry exceeded deadline")
+	}
+
+	// 间隔为0时不应空转
+	atomic.StoreInt32(&flaky.fails, 1000)
+	if _, err := Connect(Driver("sqlxb_flaky"), Retry(30*time.Millisecond), Backoff(0, 0)); err == nil {
+		t.Error("expected retry timeout")
+	}
+	if attempts := 1000 - atomic.LoadInt32(&flaky.fails); attempts > 10 {
+		t.Errorf("retry loop spun %d times", attempts)
+	}
+}
+
+func TestHealthChecker(t *testing.T) {
+	db := newTestDB(t)
+	h := NewHealthChecker(db, 5*time.Millisecond)
+	defer h.Stop()
+	if !h.Healthy() {
+		t.Fatal(h.Status().Err)
+	}
+	db.Close()
+	time.Sleep(30 * time.Millisecond)
+	if s := h.Status(); s.Healthy || s.Failures == 0 {
+		t.Errorf("expected unhealthy status, got %+v", s)
+	}
+	select {
+	case <-h.stop:
+	default:
+		t.Error("expected checker stopped after db closed")
+	}
+}
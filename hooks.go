@@ -0,0 +1,86 @@
+package builder
+
+import "reflect"
+
+// BeforeInserter INSERT前调用，返回错误则中止执行
+type BeforeInserter interface {
+	BeforeInsert() error
+}
+
+// AfterInserter INSERT成功后调用
+type AfterInserter interface {
+	AfterInsert() error
+}
+
+// BeforeUpdater UPDATE前调用，返回错误则中止执行
+type BeforeUpdater interface {
+	BeforeUpdate() error
+}
+
+// AfterUpdater UPDATE成功后调用
+type AfterUpdater interface {
+	AfterUpdate() error
+}
+
+// AfterFinder One/All 查询并赋值后调用
+type AfterFinder interface {
+	AfterFind() error
+}
+
+// callBefore 调用执行前的钩子
+func callBefore(method string, s interface{}) error {
+	switch method {
+	case "INSERT", "INSERT_IGNORE", "INSERT_ON_DUPLICATE_UPDATE":
+		if h, ok := s.(BeforeInserter); ok {
+			return h.BeforeInsert()
+		}
+	case "UPDATE":
+		if h, ok := s.(BeforeUpdater); ok {
+			return h.BeforeUpdate()
+		}
+	}
+	return nil
+}
+
+// callAfter 调用执行后的钩子
+func callAfter(method string, s interface{}) error {
+	switch method {
+	case "INSERT", "INSERT_IGNORE", "INSERT_ON_DUPLICATE_UPDATE":
+		if h, ok := s.(AfterInserter); ok {
+			return h.AfterInsert()
+		}
+	case "UPDATE":
+		if h, ok := s.(AfterUpdater); ok {
+			return h.AfterUpdate()
+		}
+	}
+	return nil
+}
+
+// callAfterFind 对单条结果或切片中的每条结果调用AfterFind
+func callAfterFind(dest interface{}) error {
+	if h, ok := dest.(AfterFinder); ok {
+		return h.AfterFind()
+	}
+	rv := reflect.ValueOf(dest)
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Slice {
+		return nil
+	}
+	for i := 0; i < rv.Len(); i++ {
+		item := rv.Index(i)
+		if item.Kind() != reflect.Ptr {
+			item = item.Addr()
+		} else if item.IsNil() {
+			continue
+		}
+		if h, ok := item.Interface().(AfterFinder); ok {
+			if err := h.AfterFind(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
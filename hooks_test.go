@@ -0,0 +1,54 @@
+package builder
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testUser struct {
+	ID     int64  `db:"id" json:"id"`
+	Name   string `db:"name" json:"name"`
+	loaded bool
+}
+
+func (u *testUser) BeforeInsert() error {
+	if u.Name == "" {
+		return errors.New("name is required")
+	}
+	u.Name = strings.ToLower(u.Name)
+	return nil
+}
+
+func (u *testUser) AfterFind() error {
+	u.loaded = true
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	db := newTestDB(t, "CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT)")
+	if _, err := NewBuilder(db).Table("user").Insert(&testUser{ID: 1}); err == nil {
+		t.Error("expected before insert error")
+	}
+	if _, err := NewBuilder(db).Table("user").Insert(&testUser{ID: 1, Name: "Alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBuilder(db).Table("user").BatchInsert([]testUser{{ID: 2, Name: "Bob"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	u := &testUser{}
+	if err := NewBuilder(db).Table("user").Where("id = ?", 1).One(u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "alice" || !u.loaded {
+		t.Errorf("hooks not applied: %+v", u)
+	}
+	var users []testUser
+	if err := NewBuilder(db).Table("user").OrderBy("id").All(&users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[1].Name != "bob" || !users[0].loaded || !users[1].loaded {
+		t.Errorf("hooks not applied: %+v", users)
+	}
+}
@@ -0,0 +1,162 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Statement 的执行方式
+const (
+	// StatementGet 查询单条数据并赋值给Dest(One)
+	StatementGet = "get"
+	// StatementSelect 查询多条数据并赋值给Dest(All)
+	StatementSelect = "select"
+	// StatementQuery 查询并返回Cursor(Rows、AllMaps等)
+	StatementQuery = "query"
+	// StatementExec 执行写操作
+	StatementExec = "exec"
+)
+
+// Statement 待执行的sql语句，拦截器可以读取或修改Query及Args
+type Statement struct {
+	// Kind 执行方式: get、select、query、exec
+	Kind string
+	// Operation 操作类型: select、insert、update、delete等
+	Operation string
+	Table     string
+	Query     string
+	Args      []interface{}
+	// Dest get/select的赋值对象
+	Dest interface{}
+
+	// 原始语句及日志中显示的隐藏敏感字段后的语句、参数
+	query       string
+	display     string
+	displayArgs []interface{}
+}
+
+// Result 执行结果
+type Result struct {
+	// Exec exec的执行结果
+	Exec sql.Result
+	// Cursor query返回的rows，由调用方关闭
+	Cursor *sqlx.Rows
+	// Rows 查询返回或执行影响的行数，未知时为-1
+	Rows int64
+}
+
+// Handler 执行Statement
+type Handler func(ctx context.Context, stmt *Statement) (*Result, error)
+
+// Interceptor 拦截器，包裹One、All、Rows及写操作的执行；
+// 可以修改stmt后调用next，也可以不调用next直接返回(如从缓存赋值stmt.Dest)
+type Interceptor func(ctx context.Context, stmt *Statement, next Handler) (*Result, error)
+
+var interceptors = struct {
+	sync.RWMutex
+	is []Interceptor
+}{}
+
+// RegisterInterceptor 注册全局拦截器，按注册顺序由外到内执行
+func RegisterInterceptor(i Interceptor) {
+	interceptors.Lock()
+	interceptors.is = append(interceptors.is, i)
+	interceptors.Unlock()
+}
+
+// Intercept 为当前builder添加拦截器，位于全局拦截器之内
+func (b *Builder) Intercept(is ...Interceptor) *Builder {
+	b.interceptors = append(append([]Interceptor{}, b.interceptors...), is...)
+	return b
+}
+
+// statement 创建待执行的语句
+func (b *Builder) statement(kind, query string, args []interface{}, dest interface{}) *Statement {
+	stmt := &Statement{Kind: kind, Operation: Operation(query), Query: query, Args: args, Dest: dest, query: query, display: query}
+	if b.query != nil {
+		stmt.Table = DefaultMapper(b.query.Table)
+	}
+	return stmt
+}
+
+// _run 依次经过拦截器后执行语句
+func (b *Builder) _run(stmt *Statement) (*Result, error) {
+	interceptors.RLock()
+	chain := append(append([]Interceptor{}, interceptors.is...), b.interceptors...)
+	interceptors.RUnlock()
+	h := Handler(b.handle)
+	for i := len(chain) - 1; i >= 0; i-- {
+		h = wrapHandler(chain[i], h)
+	}
+	result, err := h(b.context(), stmt)
+	if err == nil && result == nil {
+		return nil, errors.New("interceptor returned nil result")
+	}
+	return result, err
+}
+
+func wrapHandler(i Interceptor, next Handler) Handler {
+	return func(ctx context.Context, stmt *Statement) (*Result, error) {
+		return i(ctx, stmt, next)
+	}
+}
+
+// displayStatement 返回日志及QueryEvent中显示的语句及参数；有隐藏的敏感字段且拦截器修改了语句时，
+// 将修改应用到隐藏后的语句，无法对应时去除语句中的全部字面量
+func (b *Builder) displayStatement(stmt *Statement) (string, []interface{}) {
+	display, displayArgs := stmt.Query, []interface{}(nil)
+	if stmt.display != stmt.query || stmt.displayArgs != nil {
+		if i := strings.Index(stmt.Query, stmt.query); i >= 0 {
+			display = stmt.Query[:i] + stmt.display + stmt.Query[i+len(stmt.query):]
+			if len(stmt.displayArgs) == len(stmt.Args) {
+				displayArgs = stmt.displayArgs
+			}
+		} else {
+			display = SanitizeSQL(stmt.Query)
+		}
+	}
+	if displayArgs == nil {
+		displayArgs = b.redactArgs(display, stmt.Args)
+	}
+	return display, displayArgs
+}
+
+// handle 实际执行语句，并调用QueryHook及日志
+func (b *Builder) handle(ctx context.Context, stmt *Statement) (*Result, error) {
+	display, displayArgs := b.displayStatement(stmt)
+	ctx, e := b.beforeQuery(ctx, stmt, display, displayArgs)
+	result := &Result{Rows: -1}
+	var err error
+	switch stmt.Kind {
+	case StatementGet:
+		if err = b.readDB(stmt).GetContext(ctx, stmt.Dest, stmt.Query, stmt.Args...); err == nil {
+			result.Rows = 1
+		} else {
+			result.Rows = 0
+		}
+	case StatementSelect:
+		err = b.readDB(stmt).SelectContext(ctx, stmt.Dest, stmt.Query, stmt.Args...)
+		if v := reflect.Indirect(reflect.ValueOf(stmt.Dest)); err == nil && v.Kind() == reflect.Slice {
+			result.Rows = int64(v.Len())
+		}
+	case StatementQuery:
+		result.Cursor, err = b.readDB(stmt).QueryxContext(ctx, stmt.Query, stmt.Args...)
+	case StatementExec:
+		if result.Exec, err = b.DB().ExecContext(ctx, stmt.Query, stmt.Args...); err == nil {
+			result.Rows, _ = result.Exec.RowsAffected()
+		}
+	default:
+		err = errors.New("unknown statement kind: " + stmt.Kind)
+	}
+	b.afterQuery(ctx, e, result.Rows, err)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
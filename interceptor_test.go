@@ -0,0 +1,104 @@
+package builder
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestInterceptor(t *testing.T) {
+	db := newTestDB(t,
+		"CREATE TABLE fruit (id INTEGER PRIMARY KEY, name TEXT)",
+		"INSERT INTO fruit VALUES (1, 'apple'), (2, 'pear')",
+	)
+	var order []string
+	trace := func(name string) Interceptor {
+		return func(ctx context.Context, stmt *Statement, next Handler) (*Result, error) {
+			order = append(order, name)
+			return next(ctx, stmt)
+		}
+	}
+	RegisterInterceptor(trace("global"))
+	defer func() { interceptors.is = nil }()
+
+	// 改写sql
+	rewrite := func(ctx context.Context, stmt *Statement, next Handler) (*Result, error) {
+		stmt.Query = strings.Replace(stmt.Query, "id = ?", "id = ? + 1", 1)
+		return next(ctx, stmt)
+	}
+	var name string
+	if err := NewBuilder(db).Intercept(trace("builder"), rewrite).Table("fruit").Where("id = ?", 1).Value("name", &name); err != nil {
+		t.Fatal(err)
+	}
+	if name != "pear" || strings.Join(order, ",") != "global,builder" {
+		t.Errorf("unexpected result: %s %v", name, order)
+	}
+
+	// 短路返回缓存
+	h := &testQueryHook{}
+	cache := func(ctx context.Context, stmt *Statement, next Handler) (*Result, error) {
+		if stmt.Kind == StatementSelect {
+			*(stmt.Dest.(*[]string)) = []string{"cached"}
+			return &Result{Rows: 1}, nil
+		}
+		return next(ctx, stmt)
+	}
+	var names []string
+	if err := NewBuilder(db).QueryHook(h).Intercept(cache).Table("fruit").Pluck("name", &names); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "cached" || len(h.after) != 0 {
+		t.Errorf("expected cached result without executing: %v %d", names, len(h.after))
+	}
+	if _, err := NewBuilder(db).Intercept(cache).Table("fruit").Count(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInterceptorKeepsRedaction(t *testing.T) {
+	db := newTestDB(t, "CREATE TABLE member (id INTEGER PRIMARY KEY, password TEXT)")
+	h := &testQueryHook{}
+	tag := func(ctx context.Context, stmt *Statement, next Handler) (*Result, error) {
+		stmt.Query = "/* app */ " + stmt.Query
+		return next(ctx, stmt)
+	}
+	if _, err := NewBuilder(db).QueryHook(h).Intercept(tag).Table("member").Insert(map[string]interface{}{"id": 1, "password": "s3cret"}); err != nil {
+		t.Fatal(err)
+	}
+	upper := func(ctx context.Context, stmt *Statement, next Handler) (*Result, error) {
+		stmt.Query = strings.Replace(stmt.Query, "UPDATE", "update", 1)
+		return next(ctx, stmt)
+	}
+	if _, err := NewBuilder(db).QueryHook(h).Intercept(upper).Table("member").Where("id = ?", 1).Update(map[string]interface{}{"password": "n3w"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.before) != 2 || !strings.HasPrefix(h.before[0].Query, "/* app */ INSERT") {
+		t.Fatalf("unexpected events: %+v", h.before)
+	}
+	for _, e := range h.before {
+		if strings.Contains(e.Query, "s3cret") || strings.Contains(e.Query, "n3w") {
+			t.Errorf("sensitive value leaked: %s", e.Query)
+		}
+	}
+
+	// 查询语句没有隐藏的字段，显示原语句
+	var logged []LogEntry
+	logger := LoggerFunc(func(ctx context.Context, level LogLevel, e LogEntry) { logged = append(logged, e) })
+	h.before = nil
+	var n int64
+	if err := NewBuilder(db).Debug(true).Logger(logger).QueryHook(h).Table("member").Where("id = ?", 1).Value("id", &n); err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != 1 || logged[0].Query != "SELECT id FROM member WHERE id = ?" || len(h.before) != 1 || h.before[0].Query != logged[0].Query {
+		t.Errorf("unexpected select display: %+v %+v", logged, h.before)
+	}
+
+	for _, i := range []Interceptor{
+		func(ctx context.Context, stmt *Statement, next Handler) (*Result, error) { return nil, nil },
+		func(ctx context.Context, stmt *Statement, next Handler) (*Result, error) { return &Result{}, nil },
+	} {
+		if _, err := NewBuilder(db).Intercept(i).Exec("DELETE FROM member"); err == nil {
+			t.Error("expected error for missing exec result")
+		}
+	}
+}
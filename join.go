@@ -0,0 +1,156 @@
+package builder
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// nestedStructs 返回dest中以db tag为前缀的嵌套struct字段，如 Address Address `db:"address"`
+func nestedStructs(dest interface{}) map[string]reflect.Type {
+	result := make(map[string]reflect.Type)
+	t := reflect.TypeOf(dest)
+	if t == nil {
+		return result
+	}
+	for _, f := range structFields(t) {
+		ft := f.field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() != reflect.Struct || ft == reflect.TypeOf(time.Time{}) || reflect.PtrTo(ft).Implements(scannerType) {
+			continue
+		}
+		result[f.column] = ft
+	}
+	return result
+}
+
+// withJoinFields 多表关联且未指定字段时，为映射到嵌套struct的关联表生成带前缀的字段别名，
+// 如 address.id AS "address.id"，避免同名字段冲突；不修改当前builder
+func (b *Builder) withJoinFields(dest interface{}) *Builder {
+	if b.query == nil || len(b.query.Join) == 0 || len(b.query.Fields) > 0 {
+		return b
+	}
+	_, joinTables, _, err := b._parseJoin()
+	if err != nil {
+		return b
+	}
+	nested := nestedStructs(dest)
+	fields := []string{DefaultMapper(b.query.Table) + ".*"}
+	aliased := false
+	for _, table := range joinTables {
+		t, ok := nested[joinTableName(table)]
+		if !ok {
+			fields = append(fields, table+".*")
+			continue
+		}
+		aliased = true
+		table = joinTableName(table)
+		for _, f := range structFields(t) {
+			fields = append(fields, fmt.Sprintf(`%s.%s AS "%s.%s"`, table, f.column, table, f.column))
+		}
+	}
+	if !aliased {
+		return b
+	}
+	cp := b.Copy()
+	cp.query.Fields = fields
+	return cp
+}
+
+// joinTableName 返回join表达式中的表名或别名
+func joinTableName(table string) string {
+	parts := strings.Fields(table)
+	return parts[len(parts)-1]
+}
+
+// scanJoined 执行关联查询并赋值，嵌套struct的字段全部为NULL(如LEFT JOIN未匹配)时，
+// 指针类型保持nil，非指针类型保持零值
+func (b *Builder) scanJoined(dest interface{}, query string, args []interface{}, one bool) error {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return errors.New("dest must be a non-nil pointer")
+	}
+	dv = dv.Elem()
+	elemType := dv.Type()
+	if !one {
+		if elemType.Kind() != reflect.Slice {
+			return errors.New("dest must be a pointer to slice")
+		}
+		elemType = elemType.Elem()
+	}
+	base := indirectType(elemType)
+	rows, err := b._queryx(query, args)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	traversals := rows.Mapper.TraversalsByName(base, columns)
+	nested := nestedStructs(dest)
+	found := false
+	for rows.Next() {
+		v := reflect.New(base).Elem()
+		values := make([]interface{}, len(columns))
+		holders := make([]reflect.Value, len(columns))
+		for i, column := range columns {
+			if len(traversals[i]) == 0 {
+				if !b.unsafe {
+					return fmt.Errorf("missing destination name %s in %T", column, dest)
+				}
+				values[i] = new(interface{})
+				continue
+			}
+			if j := strings.Index(column, "."); j > 0 && nested[column[:j]] != nil {
+				// 先赋值到指针，非NULL时再写入字段，避免为未匹配的数据分配嵌套struct
+				holders[i] = reflect.New(reflect.PtrTo(fieldType(base, traversals[i])))
+				values[i] = holders[i].Interface()
+				continue
+			}
+			values[i] = reflectx.FieldByIndexes(v, traversals[i]).Addr().Interface()
+		}
+		if err := rows.Scan(values...); err != nil {
+			return err
+		}
+		for i, h := range holders {
+			if h.IsValid() && !h.Elem().IsNil() {
+				reflectx.FieldByIndexes(v, traversals[i]).Set(h.Elem().Elem())
+			}
+		}
+		if elemType.Kind() == reflect.Ptr {
+			v = v.Addr()
+		}
+		if one {
+			dv.Set(v)
+			found = true
+			break
+		}
+		dv.Set(reflect.Append(dv, v))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if one && !found {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// fieldType 返回按索引路径访问的字段类型
+func fieldType(t reflect.Type, index []int) reflect.Type {
+	for _, i := range index {
+		t = indirectType(t).Field(i).Type
+	}
+	return t
+}
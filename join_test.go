@@ -0,0 +1,87 @@
+package builder
+
+import "testing"
+
+type testJoinPerson struct {
+	ID   int64  `db:"id"`
+	Name string `db:"name"`
+}
+
+type testJoinAddress struct {
+	ID       int64  `db:"id"`
+	PersonID int64  `db:"person_id"`
+	Name     string `db:"name"`
+}
+
+type testPersonAddress struct {
+	testJoinPerson
+	Address testJoinAddress `db:"address"`
+}
+
+func TestJoinFields(t *testing.T) {
+	db := newTestDB(t,
+		"CREATE TABLE person (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE address (id INTEGER PRIMARY KEY, person_id INTEGER, name TEXT)",
+		"INSERT INTO person VALUES (1, 'tom')",
+		"INSERT INTO address VALUES (7, 1, 'home')",
+	)
+	var rows []testPersonAddress
+	b := NewBuilder(db).Table("person").Join("address", "address.person_id = person.id")
+	if err := b.All(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].ID != 1 || rows[0].Name != "tom" || rows[0].Address.ID != 7 || rows[0].Address.Name != "home" {
+		t.Errorf("unexpected rows: %+v", rows)
+	}
+	if len(b.query.Fields) != 0 {
+		t.Errorf("join fields mutated builder: %v", b.query.Fields)
+	}
+
+	var aliased struct {
+		testJoinPerson
+		Address *testJoinAddress `db:"a"`
+	}
+	if err := NewBuilder(db).Table("person").Join("address", "a", "a.person_id = person.id").One(&aliased); err != nil {
+		t.Fatal(err)
+	}
+	if aliased.Address == nil || aliased.Address.ID != 7 || aliased.ID != 1 {
+		t.Errorf("unexpected row: %+v", aliased)
+	}
+}
+
+type testJoinBase struct {
+	ID   int64  `db:"id"`
+	Name string `db:"name"`
+}
+
+type testJoinProfile struct {
+	testJoinBase
+	PersonID int64 `db:"person_id"`
+}
+
+func TestLeftJoinNested(t *testing.T) {
+	db := newTestDB(t,
+		"CREATE TABLE person (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE address (id INTEGER PRIMARY KEY, person_id INTEGER, name TEXT)",
+		"INSERT INTO person VALUES (1, 'tom'), (2, 'amy')",
+		"INSERT INTO address VALUES (7, 1, 'home')",
+	)
+	var rows []struct {
+		testJoinPerson
+		Address testJoinProfile  `db:"address"`
+		Other   *testJoinProfile `db:"other"`
+	}
+	err := NewBuilder(db).Table("person").
+		LeftJoin("address", "address.person_id = person.id").
+		LeftJoin("address", "other", "other.person_id = person.id AND other.id > 7").
+		OrderBy("person.id").All(&rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Address.ID != 7 || rows[0].Address.Name != "home" || rows[0].Other != nil {
+		t.Fatalf("unexpected rows: %+v", rows)
+	}
+	if rows[1].ID != 2 || rows[1].Address.ID != 0 || rows[1].Other != nil {
+		t.Errorf("unmatched join should leave zero value: %+v", rows[1])
+	}
+}
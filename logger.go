@@ -0,0 +1,110 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// LogLevel 日志级别
+type LogLevel int
+
+// 日志级别
+const (
+	LevelDebug LogLevel = iota
+	LevelWarn
+	LevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	default:
+		return "DEBUG"
+	}
+}
+
+// LogEntry 一次sql执行的记录
+type LogEntry struct {
+	Query   string
+	Args    []interface{}
+	Elapsed time.Duration
+	// Rows 查询返回或执行影响的行数，未知时为-1
+	Rows int64
+	Err  error
+}
+
+// Logger 日志接口，可适配zap、slog等
+type Logger interface {
+	Log(ctx context.Context, level LogLevel, entry LogEntry)
+}
+
+// LoggerFunc 函数形式的Logger
+type LoggerFunc func(ctx context.Context, level LogLevel, entry LogEntry)
+
+// Log 实现Logger
+func (f LoggerFunc) Log(ctx context.Context, level LogLevel, entry LogEntry) {
+	f(ctx, level, entry)
+}
+
+// LogTemp 打印日志格式，StdLogger使用该格式输出query及args
+//
+// Deprecated: 使用DefaultLogger或Builder.Logger自定义日志
+var LogTemp = "sql output:\nquery: %v\n args: %v\n"
+
+// StdLogger 使用标准库log输出的Logger
+type StdLogger struct{}
+
+// Log 实现Logger
+func (StdLogger) Log(ctx context.Context, level LogLevel, entry LogEntry) {
+	log.Printf("[%s] %s elapsed: %v rows: %d err: %v\n",
+		level, fmt.Sprintf(LogTemp, entry.Query, entry.Args), entry.Elapsed, entry.Rows, entry.Err)
+}
+
+var (
+	// DefaultLogger 全局Logger
+	DefaultLogger Logger = StdLogger{}
+	// DefaultSlowThreshold 全局慢查询阈值，超过时即使未开启debug也以WARN级别输出，0为不启用
+	DefaultSlowThreshold time.Duration
+)
+
+// Logger 设置当前builder的Logger
+func (b *Builder) Logger(l Logger) *Builder {
+	b.logger = l
+	return b
+}
+
+// SlowThreshold 设置当前builder的慢查询阈值
+func (b *Builder) SlowThreshold(d time.Duration) *Builder {
+	b.slowThreshold = d
+	return b
+}
+
+// logQuery 记录sql执行结果，debug时输出全部sql，否则仅输出慢查询
+func (b *Builder) logQuery(ctx context.Context, e *QueryEvent) {
+	threshold := b.slowThreshold
+	if threshold == 0 {
+		threshold = DefaultSlowThreshold
+	}
+	slow := threshold > 0 && e.Elapsed >= threshold
+	if !b.debug && !slow {
+		return
+	}
+	level := LevelDebug
+	if e.Err != nil {
+		level = LevelError
+	} else if slow {
+		level = LevelWarn
+	}
+	logger := b.logger
+	if logger == nil {
+		logger = DefaultLogger
+	}
+	if logger != nil {
+		logger.Log(ctx, level, LogEntry{Query: e.Query, Args: e.Args, Elapsed: e.Elapsed, Rows: e.Rows, Err: e.Err})
+	}
+}
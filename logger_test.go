@@ -0,0 +1,97 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogger(t *testing.T) {
+	db := newTestDB(t, "CREATE TABLE note (id INTEGER PRIMARY KEY, body TEXT)")
+	var entries []LogEntry
+	var levels []LogLevel
+	logger := LoggerFunc(func(ctx context.Context, level LogLevel, entry LogEntry) {
+		levels = append(levels, level)
+		entries = append(entries, entry)
+	})
+
+	if _, err := NewBuilder(db).Logger(logger).Table("missing").Insert(map[string]interface{}{"id": 1}); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected no logs without debug, got %v", entries)
+	}
+
+	if _, err := NewBuilder(db).Debug(true).Logger(logger).Table("note").Insert(map[string]interface{}{"id": 1, "body": "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBuilder(db).Debug(true).Logger(logger).Table("missing").Count(); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(entries) != 2 || entries[0].Rows != 1 || levels[0] != LevelDebug || levels[1] != LevelError || entries[1].Err == nil {
+		t.Errorf("unexpected logs: %v %+v", levels, entries)
+	}
+
+	entries, levels = nil, nil
+	var notes []struct {
+		ID   int64  `db:"id"`
+		Body string `db:"body"`
+	}
+	if err := NewBuilder(db).Logger(logger).SlowThreshold(time.Nanosecond).Table("note").All(&notes); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || levels[0] != LevelWarn || entries[0].Rows != 1 || entries[0].Elapsed <= 0 {
+		t.Errorf("expected slow query warning, got %v %+v", levels, entries)
+	}
+}
+
+type testQueryHook struct {
+	before, after []*QueryEvent
+}
+
+func (h *testQueryHook) BeforeQuery(ctx context.Context, e *QueryEvent) context.Context {
+	h.before = append(h.before, e)
+	return ctx
+}
+
+func (h *testQueryHook) AfterQuery(ctx context.Context, e *QueryEvent) {
+	h.after = append(h.after, e)
+}
+
+func TestQueryHook(t *testing.T) {
+	db := newTestDB(t, "CREATE TABLE note (id INTEGER PRIMARY KEY, body TEXT)")
+	h := &testQueryHook{}
+	if _, err := NewBuilder(db).QueryHook(h).Table("note").Comment("c").Insert(map[string]interface{}{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBuilder(db).QueryHook(h).Table("note").Count(); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.before) != 2 || len(h.after) != 2 {
+		t.Fatalf("unexpected hook calls: %d %d", len(h.before), len(h.after))
+	}
+	e := h.after[0]
+	if e.Operation != "insert" || e.Table != "note" || e.Driver != "sqlite3" || e.Rows != 1 || e.Err != nil {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if h.after[1].Operation != "select" || h.after[1].Rows != 1 {
+		t.Errorf("unexpected event: %+v", h.after[1])
+	}
+}
+
+func TestLogTemp(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	old := LogTemp
+	LogTemp = "custom %v | %v"
+	defer func() { LogTemp = old }()
+	StdLogger{}.Log(context.Background(), LevelDebug, LogEntry{Query: "SELECT 1", Args: []interface{}{2}})
+	if !strings.Contains(buf.String(), "custom SELECT 1 | [2]") {
+		t.Errorf("LogTemp not honoured: %s", buf.String())
+	}
+}
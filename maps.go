@@ -0,0 +1,99 @@
+package builder
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// OneMap 返回单条数据，字段名为key
+func (b *Builder) OneMap() (map[string]interface{}, error) {
+	result, err := b.queryMaps(true)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return result[0], nil
+}
+
+// AllMaps 返回多条数据，字段名为key
+func (b *Builder) AllMaps() ([]map[string]interface{}, error) {
+	return b.queryMaps(false)
+}
+
+// ColumnTypes 返回查询结果的字段类型，不读取数据
+func (b *Builder) ColumnTypes() ([]*sql.ColumnType, error) {
+	query, args, err := b.Copy().Where("1 = 0").BuildQuery()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := b._queryx(query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return rows.ColumnTypes()
+}
+
+// queryMaps 执行查询并按字段类型转换驱动返回的[]byte
+func (b *Builder) queryMaps(one bool) ([]map[string]interface{}, error) {
+	query, args, err := b.BuildQuery()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := b._queryx(query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	result := []map[string]interface{}{}
+	for rows.Next() {
+		row := make(map[string]interface{}, len(types))
+		if err := rows.MapScan(row); err != nil {
+			return nil, err
+		}
+		for _, t := range types {
+			row[t.Name()] = ConvertValue(t.DatabaseTypeName(), row[t.Name()])
+		}
+		result = append(result, row)
+		if one {
+			break
+		}
+	}
+	return result, rows.Err()
+}
+
+// integerTypes 各驱动返回的整数类型名，mysql的无符号类型去掉UNSIGNED后匹配
+var integerTypes = map[string]bool{
+	"TINYINT": true, "SMALLINT": true, "MEDIUMINT": true, "INT": true, "INTEGER": true, "BIGINT": true,
+	"INT2": true, "INT4": true, "INT8": true, "YEAR": true,
+}
+
+// ConvertValue 按数据库字段类型转换驱动返回的[]byte，整数转int64，浮点转float64，
+// DECIMAL保持字符串以免丢失精度，二进制类型保持[]byte，其他转为字符串
+func ConvertValue(typeName string, v interface{}) interface{} {
+	raw, ok := v.([]byte)
+	if !ok {
+		return v
+	}
+	typeName = strings.ToUpper(typeName)
+	switch {
+	case strings.Contains(typeName, "BLOB"), strings.Contains(typeName, "BINARY"), typeName == "BYTEA", typeName == "BIT":
+		return raw
+	case integerTypes[strings.TrimSpace(strings.Replace(typeName, "UNSIGNED", "", 1))]:
+		if n, err := strconv.ParseInt(string(raw), 10, 64); err == nil {
+			return n
+		}
+	case typeName == "FLOAT", typeName == "DOUBLE", typeName == "REAL", strings.HasPrefix(typeName, "FLOAT"):
+		if n, err := strconv.ParseFloat(string(raw), 64); err == nil {
+			return n
+		}
+	}
+	return string(raw)
+}
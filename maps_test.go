@@ -0,0 +1,58 @@
+package builder
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConvertValue(t *testing.T) {
+	cases := []struct {
+		typeName string
+		in       interface{}
+		out      interface{}
+	}{
+		{"BIGINT", []byte("42"), int64(42)},
+		{"UNSIGNED INT", []byte("7"), int64(7)},
+		{"int4", []byte("8"), int64(8)},
+		{"POINT", []byte("12"), "12"},
+		{"INTERVAL", []byte("10"), "10"},
+		{"DOUBLE", []byte("1.5"), 1.5},
+		{"DECIMAL", []byte("1.10"), "1.10"},
+		{"VARCHAR", []byte("abc"), "abc"},
+		{"BLOB", []byte{1, 2}, []byte{1, 2}},
+		{"INT", int64(3), int64(3)},
+		{"VARCHAR", nil, nil},
+	}
+	for _, c := range cases {
+		if got := ConvertValue(c.typeName, c.in); !reflect.DeepEqual(got, c.out) {
+			t.Errorf("ConvertValue(%s, %v) = %#v, want %#v", c.typeName, c.in, got, c.out)
+		}
+	}
+}
+
+func TestMaps(t *testing.T) {
+	db := newTestDB(t,
+		"CREATE TABLE product (id INTEGER PRIMARY KEY, name VARCHAR(20), price DOUBLE)",
+		"INSERT INTO product VALUES (1, 'a', 1.5), (2, 'b', 2.5)",
+	)
+	rows, err := NewBuilder(db).Table("product").OrderBy("id").AllMaps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[1]["name"] != "b" || rows[1]["price"] != 2.5 || rows[0]["id"] != int64(1) {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+	row, err := NewBuilder(db).Table("product").Where("id = ?", 2).OneMap()
+	if err != nil || row["name"] != "b" {
+		t.Errorf("unexpected row: %v %v", row, err)
+	}
+	if _, err := NewBuilder(db).Table("product").Where("id = ?", 3).OneMap(); err != sql.ErrNoRows {
+		t.Errorf("expected sql.ErrNoRows, got %v", err)
+	}
+	types, err := NewBuilder(db).Table("product").ColumnTypes()
+	if err != nil || len(types) != 3 || !strings.HasPrefix(types[1].DatabaseTypeName(), "VARCHAR") {
+		t.Errorf("unexpected column types: %v %v", types, err)
+	}
+}
@@ -0,0 +1,530 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// DefaultMigrationTable 记录已执行迁移的数据表
+	DefaultMigrationTable = "schema_migrations"
+	// DefaultMigrationLockTimeout 等待其他实例释放迁移锁的时间
+	DefaultMigrationLockTimeout = time.Minute
+	// DefaultMigrationLockExpiry 锁表中的锁超过该时间视为持有者已崩溃，可被接管
+	DefaultMigrationLockExpiry = time.Minute * 10
+	// ErrMigrationLocked 等待迁移锁超时
+	ErrMigrationLocked = errors.New("migration is locked by another instance")
+)
+
+// MigrateFunc 迁移函数，b已绑定迁移所在的事务，dry-run时写操作只输出不执行
+type MigrateFunc func(b *Builder) error
+
+// Migration 一个版本的迁移，Up/Down为Go函数或sql语句，sql中多条语句以;分隔
+type Migration struct {
+	Version int64
+	Name    string
+	Up      MigrateFunc
+	Down    MigrateFunc
+	UpSQL   string
+	DownSQL string
+}
+
+// MigrationStatus 迁移的执行状态
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator 按版本执行迁移，执行记录保存在迁移表中
+type Migrator struct {
+	db          *sqlx.DB
+	table       string
+	lockTimeout time.Duration
+	lockExpiry  time.Duration
+	dryRun      io.Writer
+	migrations  map[int64]*Migration
+}
+
+// MigratorOption Migrator参数函数
+type MigratorOption func(m *Migrator)
+
+// MigrationTable 设置迁移表名
+func MigrationTable(name string) MigratorOption {
+	return func(m *Migrator) {
+		m.table = name
+	}
+}
+
+// MigrationLockTimeout 设置等待迁移锁的时间
+func MigrationLockTimeout(d time.Duration) MigratorOption {
+	return func(m *Migrator) {
+		m.lockTimeout = d
+	}
+}
+
+// MigrationLockExpiry 设置锁表中锁的过期时间，0为永不过期；仅用于非mysql/postgres数据库
+func MigrationLockExpiry(d time.Duration) MigratorOption {
+	return func(m *Migrator) {
+		m.lockExpiry = d
+	}
+}
+
+// DryRun 只将要执行的写操作sql输出到w，不修改数据库
+func DryRun(w io.Writer) MigratorOption {
+	return func(m *Migrator) {
+		m.dryRun = w
+	}
+}
+
+// NewMigrator 创建Migrator
+func NewMigrator(db *sqlx.DB, opts ...MigratorOption) *Migrator {
+	m := &Migrator{
+		db:          db,
+		table:       DefaultMigrationTable,
+		lockTimeout: DefaultMigrationLockTimeout,
+		lockExpiry:  DefaultMigrationLockExpiry,
+		migrations:  make(map[int64]*Migration),
+	}
+	for _, o := range opts {
+		o(m)
+	}
+	return m
+}
+
+// Add 添加迁移，版本重复时返回错误
+func (m *Migrator) Add(migrations ...*Migration) error {
+	for _, mg := range migrations {
+		if _, ok := m.migrations[mg.Version]; ok {
+			return fmt.Errorf("duplicate migration version %d", mg.Version)
+		}
+		m.migrations[mg.Version] = mg
+	}
+	return nil
+}
+
+// Up 执行全部未执行的迁移
+func (m *Migrator) Up() error {
+	return m.UpContext(context.Background())
+}
+
+// UpContext 执行全部未执行的迁移
+func (m *Migrator) UpContext(ctx context.Context) error {
+	return m.migrate(ctx, func(applied map[int64]MigrationStatus) []migrationStep {
+		return m.pending(applied, -1)
+	})
+}
+
+// Down 回滚最后执行的一个迁移
+func (m *Migrator) Down() error {
+	return m.DownContext(context.Background())
+}
+
+// DownContext 回滚最后执行的一个迁移
+func (m *Migrator) DownContext(ctx context.Context) error {
+	return m.migrate(ctx, func(applied map[int64]MigrationStatus) []migrationStep {
+		versions := appliedVersions(applied)
+		if len(versions) == 0 {
+			return nil
+		}
+		return []migrationStep{{m.migrationOf(versions[len(versions)-1], applied), false}}
+	})
+}
+
+// To 迁移到指定版本，倒序回滚大于version的已执行迁移，再执行不超过version的未执行迁移
+func (m *Migrator) To(version int64) error {
+	return m.ToContext(context.Background(), version)
+}
+
+// ToContext 迁移到指定版本
+func (m *Migrator) ToContext(ctx context.Context, version int64) error {
+	return m.migrate(ctx, func(applied map[int64]MigrationStatus) []migrationStep {
+		var steps []migrationStep
+		versions := appliedVersions(applied)
+		for i := len(versions) - 1; i >= 0 && versions[i] > version; i-- {
+			steps = append(steps, migrationStep{m.migrationOf(versions[i], applied), false})
+		}
+		return append(steps, m.pending(applied, version)...)
+	})
+}
+
+// Status 返回全部迁移的执行状态，包括已执行但未添加的迁移
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var result []MigrationStatus
+	for v, mg := range m.migrations {
+		s := applied[v]
+		s.Version, s.Name = v, mg.Name
+		result = append(result, s)
+	}
+	for v, s := range applied {
+		if _, ok := m.migrations[v]; !ok {
+			result = append(result, s)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+// migrationStep 执行或回滚一个迁移
+type migrationStep struct {
+	*Migration
+	up bool
+}
+
+// pending 按版本顺序返回不超过max的未执行迁移，max小于0时不限制
+func (m *Migrator) pending(applied map[int64]MigrationStatus, max int64) []migrationStep {
+	var result []migrationStep
+	for v, mg := range m.migrations {
+		if _, ok := applied[v]; !ok && (max < 0 || v <= max) {
+			result = append(result, migrationStep{mg, true})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result
+}
+
+// migrationOf 返回已执行的迁移，未添加时返回仅有版本号的迁移，回滚时报错
+func (m *Migrator) migrationOf(version int64, applied map[int64]MigrationStatus) *Migration {
+	if mg, ok := m.migrations[version]; ok {
+		return mg
+	}
+	return &Migration{Version: version, Name: applied[version].Name}
+}
+
+func appliedVersions(applied map[int64]MigrationStatus) []int64 {
+	var versions []int64
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
+
+// migrate 加锁后按plan返回的顺序执行或回滚迁移
+func (m *Migrator) migrate(ctx context.Context, plan func(applied map[int64]MigrationStatus) []migrationStep) error {
+	if err := m.createTable(ctx); err != nil {
+		return err
+	}
+	if m.dryRun == nil {
+		unlock, err := m.lock(ctx)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	for _, step := range plan(applied) {
+		if err := m.run(ctx, step.Migration, step.up); err != nil {
+			return fmt.Errorf("migration %d %s: %w", step.Version, step.Name, err)
+		}
+	}
+	return nil
+}
+
+// run 在事务中执行一个迁移并更新迁移表
+func (m *Migrator) run(ctx context.Context, mg *Migration, up bool) error {
+	f, query := mg.Up, mg.UpSQL
+	if !up {
+		f, query = mg.Down, mg.DownSQL
+	}
+	if f == nil && query == "" && !up {
+		return errors.New("down migration not found")
+	}
+	var tx *sqlx.Tx
+	b := m.builder(ctx)
+	if m.dryRun != nil {
+		fmt.Fprintf(m.dryRun, "-- %s %d %s\n", If(up, "up", "down"), mg.Version, mg.Name)
+	} else {
+		var err error
+		if tx, err = m.db.BeginTxx(ctx, nil); err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		b.SetTx(tx)
+	}
+	if f != nil {
+		if err := f(b); err != nil {
+			return err
+		}
+	}
+	for _, s := range splitSQL(query) {
+		if _, err := b.Exec(s); err != nil {
+			return err
+		}
+	}
+	var err error
+	if up {
+		_, err = b.Exec(m.db.Rebind("INSERT INTO "+m.table+" (version, name, applied_at) VALUES (?, ?, ?)"), mg.Version, mg.Name, NowFunc())
+	} else {
+		_, err = b.Exec(m.db.Rebind("DELETE FROM "+m.table+" WHERE version = ?"), mg.Version)
+	}
+	if err != nil || tx == nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// builder 返回执行迁移的builder，dry-run时写操作只输出
+func (m *Migrator) builder(ctx context.Context) *Builder {
+	b := NewBuilder(m.db).WithContext(ctx)
+	if m.dryRun == nil {
+		return b
+	}
+	return b.Intercept(func(ctx context.Context, stmt *Statement, next Handler) (*Result, error) {
+		if stmt.Kind != StatementExec {
+			return next(ctx, stmt)
+		}
+		query, err := Interpolate(m.db.DriverName(), stmt.Query, stmt.Args)
+		if err != nil {
+			query = fmt.Sprintf("%s -- args: %v", stmt.Query, stmt.Args)
+		}
+		fmt.Fprintln(m.dryRun, strings.TrimRight(strings.TrimSpace(query), ";")+";")
+		return &Result{Exec: dryRunResult{}, Rows: 0}, nil
+	})
+}
+
+// dryRunResult dry-run时未执行的写操作结果
+type dryRunResult struct{}
+
+// LastInsertId 实现sql.Result
+func (dryRunResult) LastInsertId() (int64, error) {
+	return 0, nil
+}
+
+// RowsAffected 实现sql.Result
+func (dryRunResult) RowsAffected() (int64, error) {
+	return 0, nil
+}
+
+// createTable 创建迁移表
+func (m *Migrator) createTable(ctx context.Context) error {
+	_, err := m.builder(ctx).Exec("CREATE TABLE IF NOT EXISTS " + m.table +
+		" (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at TIMESTAMP NOT NULL)")
+	return err
+}
+
+// applied 返回已执行的迁移，dry-run时迁移表可能不存在
+func (m *Migrator) applied(ctx context.Context) (map[int64]MigrationStatus, error) {
+	var rows []struct {
+		Version   int64     `db:"version"`
+		Name      string    `db:"name"`
+		AppliedAt time.Time `db:"applied_at"`
+	}
+	err := m.db.SelectContext(ctx, &rows, "SELECT version, name, applied_at FROM "+m.table)
+	if err != nil && m.dryRun == nil {
+		return nil, err
+	}
+	result := make(map[int64]MigrationStatus, len(rows))
+	for _, r := range rows {
+		result[r.Version] = MigrationStatus{Version: r.Version, Name: r.Name, Applied: true, AppliedAt: r.AppliedAt}
+	}
+	return result, nil
+}
+
+// lock 获取迁移锁，mysql使用GET_LOCK，postgres使用advisory lock，其他数据库使用锁表
+func (m *Migrator) lock(ctx context.Context) (func() error, error) {
+	switch m.db.DriverName() {
+	case "mysql":
+		conn, err := m.db.Conn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var ok sql.NullInt64
+		err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", m.table, int(m.lockTimeout.Seconds())).Scan(&ok)
+		if err != nil || ok.Int64 != 1 {
+			conn.Close()
+			return nil, If(err != nil, err, ErrMigrationLocked).(error)
+		}
+		return func() error {
+			defer conn.Close()
+			_, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", m.table)
+			return err
+		}, nil
+	case "postgres", "pgx":
+		conn, err := m.db.Conn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		key := int64(crc32.ChecksumIEEE([]byte(m.table)))
+		err = m.retryLock(ctx, func() (bool, error) {
+			var ok bool
+			err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok)
+			return ok, err
+		})
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return func() error {
+			defer conn.Close()
+			_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key)
+			return err
+		}, nil
+	}
+	table := m.table + "_lock"
+	if _, err := m.db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+table+" (id INT NOT NULL PRIMARY KEY, locked_at TIMESTAMP NOT NULL)"); err != nil {
+		return nil, err
+	}
+	// 锁时间精确到秒，避免不同数据库时间精度不同导致释放时匹配不到
+	lockedAt := NowFunc().Truncate(time.Second)
+	err := m.retryLock(ctx, func() (bool, error) {
+		if m.lockExpiry > 0 {
+			// 接管持有者崩溃后遗留的过期锁
+			expired := NowFunc().Add(-m.lockExpiry)
+			if _, err := m.db.ExecContext(ctx, m.db.Rebind("DELETE FROM "+table+" WHERE id = 1 AND locked_at < ?"), expired); err != nil {
+				return false, err
+			}
+		}
+		_, err := m.db.ExecContext(ctx, m.db.Rebind("INSERT INTO "+table+" (id, locked_at) VALUES (1, ?)"), lockedAt)
+		// 主键冲突说明锁被占用，其他错误直接返回
+		if err != nil && isDuplicateKey(err) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func() error {
+		// 只释放自己持有的锁，锁过期被接管后不影响新的持有者
+		_, err := m.db.ExecContext(context.Background(), m.db.Rebind("DELETE FROM "+table+" WHERE id = 1 AND locked_at = ?"), lockedAt)
+		return err
+	}, nil
+}
+
+// isDuplicateKey 判断是否为主键冲突错误，按各驱动的错误信息判断以免依赖驱动包
+func isDuplicateKey(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"duplicate entry", "duplicate key", "primary key constraint", "unique constraint"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryLock 重试获取锁直到超时
+func (m *Migrator) retryLock(ctx context.Context, try func() (bool, error)) error {
+	deadline := time.Now().Add(m.lockTimeout)
+	for {
+		ok, err := try()
+		if err != nil || ok {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return ErrMigrationLocked
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// 以$tag$开始的postgres美元引号
+var dollarQuote = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+// splitSQL 按;拆分多条sql语句，忽略引号、美元引号($$ ... $$)及注释中的;，
+// 以及触发器、存储过程中 BEGIN ... END 块内的;
+func splitSQL(query string) []string {
+	var result []string
+	var quote byte
+	depth := 0
+	start := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(query)
+			}
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			if end := strings.Index(query[i:], "*/"); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(query)
+			}
+		case c == '$' && (i == 0 || !isIdentByte(query[i-1])):
+			tag := dollarQuote.FindString(query[i:])
+			if tag == "" {
+				continue
+			}
+			if end := strings.Index(query[i+len(tag):], tag); end >= 0 {
+				i += len(tag) + end + len(tag) - 1
+			} else {
+				i = len(query)
+			}
+		case isKeywordAt(query, i, "BEGIN") && !isTransactionBegin(query[i+len("BEGIN"):]), isKeywordAt(query, i, "CASE"):
+			depth++
+		case isKeywordAt(query, i, "END") && depth > 0:
+			// END IF、END LOOP等结束的控制语句没有计入层级
+			next := strings.Fields(strings.ToUpper(query[i+len("END"):]))
+			if len(next) == 0 || indexOf(strings.TrimRight(next[0], ";"), []string{"IF", "LOOP", "WHILE", "REPEAT"}) == -1 {
+				depth--
+			}
+		case c == ';' && depth == 0:
+			result = appendStatement(result, query[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(query) {
+		result = appendStatement(result, query[start:])
+	}
+	return result
+}
+
+// isKeywordAt 判断位置i是否为完整的关键字(不区分大小写)
+func isKeywordAt(query string, i int, keyword string) bool {
+	if i > 0 && isIdentByte(query[i-1]) || len(query) < i+len(keyword) || !strings.EqualFold(query[i:i+len(keyword)], keyword) {
+		return false
+	}
+	return len(query) == i+len(keyword) || !isIdentByte(query[i+len(keyword)])
+}
+
+// isTransactionBegin 判断BEGIN是否为开始事务的语句，而不是语句块
+func isTransactionBegin(rest string) bool {
+	rest = strings.TrimSpace(rest)
+	if rest == "" || rest[0] == ';' {
+		return true
+	}
+	words := strings.FieldsFunc(rest, func(r rune) bool { return r > 127 || !isIdentByte(byte(r)) })
+	return len(words) > 0 && indexOf(strings.ToUpper(words[0]), []string{"TRANSACTION", "WORK", "DEFERRED", "IMMEDIATE", "EXCLUSIVE"}) != -1
+}
+
+// appendStatement 添加非空语句，仅有注释的语句忽略
+func appendStatement(result []string, s string) []string {
+	s = strings.TrimSpace(s)
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			return append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,64 @@
+//go:build go1.16
+// +build go1.16
+
+package builder
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// AddFS 从fsys的dir目录添加sql迁移，支持embed.FS；文件名格式为 版本号_名称.up.sql 及 版本号_名称.down.sql，
+// 如 0001_create_person.up.sql
+func (m *Migrator) AddFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	migrations := make(map[int64]*Migration)
+	var versions []int64
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		base := strings.TrimSuffix(name, ".sql")
+		up := strings.HasSuffix(base, ".up")
+		if !up && !strings.HasSuffix(base, ".down") {
+			return fmt.Errorf("migration file %s must end with .up.sql or .down.sql", name)
+		}
+		base = strings.TrimSuffix(strings.TrimSuffix(base, ".up"), ".down")
+		parts := strings.SplitN(base, "_", 2)
+		version, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid migration version in %s: %w", name, err)
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		mg := migrations[version]
+		if mg == nil {
+			mg = &Migration{Version: version}
+			if len(parts) == 2 {
+				mg.Name = parts[1]
+			}
+			migrations[version] = mg
+			versions = append(versions, version)
+		}
+		if up {
+			mg.UpSQL = string(data)
+		} else {
+			mg.DownSQL = string(data)
+		}
+	}
+	for _, v := range versions {
+		if err := m.Add(migrations[v]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
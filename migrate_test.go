@@ -0,0 +1,159 @@
+//go:build go1.16
+// +build go1.16
+
+package builder
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestMigrator(t *testing.T) {
+	db := newTestDB(t)
+	fsys := fstest.MapFS{
+		"migrations/0001_create_person.up.sql":   {Data: []byte("-- 用户\nCREATE TABLE person (id INTEGER PRIMARY KEY, name TEXT);\nINSERT INTO person (name) VALUES ('a;b');")},
+		"migrations/0001_create_person.down.sql": {Data: []byte("DROP TABLE person;")},
+		"migrations/0003_create_tag.up.sql":      {Data: []byte("CREATE TABLE tag (id INTEGER PRIMARY KEY)")},
+		"migrations/0003_create_tag.down.sql":    {Data: []byte("DROP TABLE tag")},
+	}
+	m := NewMigrator(db)
+	if err := m.AddFS(fsys, "migrations"); err != nil {
+		t.Fatal(err)
+	}
+	err := m.Add(&Migration{
+		Version: 2,
+		Name:    "seed",
+		Up: func(b *Builder) error {
+			_, err := b.Table("person").Fields("name").Insert(map[string]interface{}{"name": "tom"})
+			return err
+		},
+		Down: func(b *Builder) error {
+			_, err := b.Exec("DELETE FROM person WHERE name = ?", "tom")
+			return err
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Add(&Migration{Version: 2}); err == nil {
+		t.Error("expected duplicate version error")
+	}
+
+	var out bytes.Buffer
+	dry := NewMigrator(db, DryRun(&out))
+	dry.AddFS(fsys, "migrations")
+	dry.Add(&Migration{Version: 4, Up: func(b *Builder) error {
+		res, err := b.Exec("UPDATE person SET name = ?", "x")
+		if err != nil {
+			return err
+		}
+		_, err = res.RowsAffected()
+		return err
+	}})
+	if err := dry.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "INSERT INTO person (name) VALUES ('a;b');") || !strings.Contains(out.String(), "-- up 3 create_tag") {
+		t.Errorf("unexpected dry run output:\n%s", out.String())
+	}
+	if err := db.Get(new(int), "SELECT COUNT(*) FROM person"); err == nil {
+		t.Error("dry run should not create tables")
+	}
+
+	if err := m.To(2); err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	db.Get(&count, "SELECT COUNT(*) FROM person")
+	if count != 2 {
+		t.Errorf("expected 2 persons, got %d", count)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	status, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 3 || !status[2].Applied || status[2].Name != "create_tag" {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	if err := m.Down(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.To(0); err != nil {
+		t.Fatal(err)
+	}
+	status, _ = m.Status()
+	for _, s := range status {
+		if s.Applied {
+			t.Errorf("expected %d rolled back", s.Version)
+		}
+	}
+	if err := db.Get(new(int), "SELECT COUNT(*) FROM person"); err == nil {
+		t.Error("expected person table dropped")
+	}
+}
+
+func TestMigrationLock(t *testing.T) {
+	db := newTestDB(t)
+	m := NewMigrator(db, MigrationLockTimeout(0))
+	unlock, err := m.lock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != ErrMigrationLocked {
+		t.Errorf("expected ErrMigrationLocked, got %v", err)
+	}
+	unlock()
+	if err := m.Up(); err != nil {
+		t.Error(err)
+	}
+
+	// 持有者崩溃遗留的锁过期后被接管
+	now := NowFunc()
+	NowFunc = func() time.Time { return now.Add(-time.Hour) }
+	if _, err := m.lock(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	NowFunc = time.Now
+	defer func() { NowFunc = time.Now }()
+	if err := NewMigrator(db, MigrationLockTimeout(0), MigrationLockExpiry(0)).Up(); err != ErrMigrationLocked {
+		t.Errorf("expected ErrMigrationLocked without expiry, got %v", err)
+	}
+	unlock, err = m.lock(context.Background())
+	if err != nil {
+		t.Fatalf("expected expired lock taken over, got %v", err)
+	}
+	if err := unlock(); err != nil {
+		t.Error(err)
+	}
+
+	// 非主键冲突的错误直接返回
+	db = newTestDB(t, "CREATE TABLE broken_lock (id INT NOT NULL PRIMARY KEY)")
+	if _, err := NewMigrator(db, MigrationTable("broken"), MigrationLockTimeout(0)).lock(context.Background()); err == nil || err == ErrMigrationLocked {
+		t.Errorf("expected insert error, got %v", err)
+	}
+}
+
+func TestSplitSQL(t *testing.T) {
+	cases := map[string]int{
+		"CREATE TABLE a (id INT); INSERT /* y; */ INTO a VALUES (';'); -- x;\n":                                      2,
+		"CREATE FUNCTION f() RETURNS trigger AS $$ BEGIN NEW.a := 1; RETURN NEW; END; $$ LANGUAGE plpgsql; SELECT 1": 2,
+		"CREATE FUNCTION g() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql; SELECT $1":                         2,
+		"CREATE TRIGGER t AFTER INSERT ON a FOR EACH ROW BEGIN\n  IF NEW.id > 0 THEN UPDATE b SET n = n + 1; END IF;\n  " +
+			"UPDATE c SET v = CASE WHEN NEW.id > 1 THEN 1 ELSE 0 END;\nEND; SELECT 1": 2,
+		"BEGIN; INSERT INTO a VALUES (1); COMMIT;":          3,
+		"BEGIN TRANSACTION; UPDATE a SET begin_at = 1; END": 3,
+	}
+	for query, n := range cases {
+		if stmts := splitSQL(query); len(stmts) != n {
+			t.Errorf("expected %d statements, got %d: %q", n, len(stmts), stmts)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package builder
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// TagName 模型声明使用的struct tag，如 `sqlxb:"created"`
+var TagName = "sqlxb"
+
+// Model 数据表模型定义，通过RegisterModel注册后由builder自动识别
+type Model struct {
+	Table string
+	// SoftDelete 软删除字段，为空则不启用软删除
+	SoftDelete string
+	// CreatedAt 创建时间字段，INSERT时自动填充
+	CreatedAt string
+	// UpdatedAt 更新时间字段，INSERT及UPDATE时自动填充
+	UpdatedAt string
+	// AutoTimestamps 未声明CreatedAt、UpdatedAt时，按CreatedColumns、UpdatedColumns约定填充数据中存在的字段
+	AutoTimestamps bool
+	// Tenant 租户字段，为空则不启用多租户隔离
+	Tenant string
+	// Sensitive 敏感字段，ToSQL及日志中隐藏其值
+	Sensitive []string
+	// Sharding 分片策略，为空则不分片
+	Sharding ShardStrategy
+	// scopes 全局查询范围，按注册顺序应用
+	scopes []namedScope
+}
+
+// ModelOption 模型参数函数
+type ModelOption func(m *Model)
+
+var models = struct {
+	sync.RWMutex
+	m map[string]*Model
+}{m: make(map[string]*Model)}
+
+// RegisterModel 注册数据表模型，重复注册会覆盖之前的定义；
+// 已有的全局查询范围(如RegisterScope注册的)会保留，同名范围以本次注册为准
+func RegisterModel(table string, opts ...ModelOption) *Model {
+	m := &Model{Table: table}
+	for _, o := range opts {
+		o(m)
+	}
+	models.Lock()
+	defer models.Unlock()
+	key := DefaultMapper(table)
+	if old, ok := models.m[key]; ok {
+		var scopes []namedScope
+		for _, s := range old.scopes {
+			if !hasScope(m.scopes, s.name) {
+				scopes = append(scopes, s)
+			}
+		}
+		m.scopes = append(scopes, m.scopes...)
+	}
+	models.m[key] = m
+	return m
+}
+
+// UnregisterModel 注销数据表模型
+func UnregisterModel(table string) {
+	models.Lock()
+	delete(models.m, DefaultMapper(table))
+	models.Unlock()
+}
+
+// GetModel 返回已注册的数据表模型，未注册返回nil
+func GetModel(table string) *Model {
+	models.RLock()
+	defer models.RUnlock()
+	return models.m[DefaultMapper(table)]
+}
+
+// model 返回当前表注册的模型
+func (b *Builder) model() *Model {
+	if b.query == nil {
+		return nil
+	}
+	return GetModel(b.query.Table)
+}
+
+// SoftDelete 设置软删除字段，如 deleted_at
+func SoftDelete(column string) ModelOption {
+	return func(m *Model) {
+		m.SoftDelete = column
+	}
+}
+
+// Timestamps 设置自动维护的创建时间及更新时间字段，传空字符串则不维护
+func Timestamps(created, updated string) ModelOption {
+	return func(m *Model) {
+		m.CreatedAt = created
+		m.UpdatedAt = updated
+	}
+}
+
+// AutoTimestamps 按约定字段名自动维护时间字段，见CreatedColumns、UpdatedColumns
+func AutoTimestamps() ModelOption {
+	return func(m *Model) {
+		m.AutoTimestamps = true
+	}
+}
+
+// FromStruct 从struct tag读取模型声明
+//
+//	CreateAt string `db:"create_at" sqlxb:"created"`
+//	UpdateAt string `db:"update_at" sqlxb:"updated"`
+//	DeletedAt *time.Time `db:"deleted_at" sqlxb:"soft_delete"`
+//	TenantID int64 `db:"tenant_id" sqlxb:"tenant"`
+//	Password string `db:"password" sqlxb:"sensitive"`
+func FromStruct(v interface{}) ModelOption {
+	return func(m *Model) {
+		for _, f := range structFields(reflect.TypeOf(v)) {
+			if _, ok := f.tags["created"]; ok {
+				m.CreatedAt = f.column
+			}
+			if _, ok := f.tags["updated"]; ok {
+				m.UpdatedAt = f.column
+			}
+			if _, ok := f.tags["soft_delete"]; ok {
+				m.SoftDelete = f.column
+			}
+			if _, ok := f.tags["tenant"]; ok {
+				m.Tenant = f.column
+			}
+			if _, ok := f.tags["sensitive"]; ok {
+				m.Sensitive = append(m.Sensitive, f.column)
+			}
+		}
+	}
+}
+
+// structField struct字段及其对应的数据表字段
+type structField struct {
+	field  reflect.StructField
+	column string
+	tags   map[string]string
+}
+
+// structFields 解析struct字段，字段名依次取 db、json tag，否则使用DefaultMapper；
+// 没有db tag的匿名嵌入struct展开为上层字段
+func structFields(t reflect.Type) []structField {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return appendStructFields(nil, t, nil)
+}
+
+func appendStructFields(result []structField, t reflect.Type, index []int) []structField {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		f.Index = append(append([]int{}, index...), i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Type != timeType && f.Tag.Get("db") == "" {
+			result = appendStructFields(result, f.Type, f.Index)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		column := columnName(f)
+		if column == "-" {
+			continue
+		}
+		result = append(result, structField{field: f, column: column, tags: parseTag(f.Tag.Get(TagName))})
+	}
+	return result
+}
+
+// columnName 返回字段对应的数据表字段名
+func columnName(f reflect.StructField) string {
+	for _, key := range []string{"db", "json"} {
+		if tag := f.Tag.Get(key); tag != "" {
+			if name := strings.Split(tag, ",")[0]; name != "" {
+				return name
+			}
+		}
+	}
+	return DefaultMapper(f.Name)
+}
+
+// parseTag 解析tag，格式为 key1,key2:value2
+func parseTag(tag string) map[string]string {
+	result := make(map[string]string)
+	for _, item := range strings.Split(tag, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kv := strings.SplitN(item, ":", 2)
+		if len(kv) == 2 {
+			result[kv[0]] = kv[1]
+		} else {
+			result[kv[0]] = ""
+		}
+	}
+	return result
+}
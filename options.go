@@ -1,6 +1,12 @@
 package builder
 
-import "time"
+import (
+	"context"
+	"database/sql/driver"
+	"net/url"
+	"strconv"
+	"time"
+)
 
 var (
 	// DefaultDriver 数据库引擎, 默认sqlite3
@@ -14,18 +20,57 @@ var (
 	DefaultParseTime = true
 	// DefaultMaxClient 最大客户端连接数，默认为10
 	DefaultMaxClient = 10
+	// DefaultName 连接名称，用于监控等区分多个连接
+	DefaultName = "default"
+	// DefaultMaxIdle 最大空闲连接数，小于0时与MaxClient相同
+	DefaultMaxIdle = -1
 	// DefaultMaxLifetime 默认空闲超时时间
 	DefaultMaxLifetime = time.Minute * 10
+	// DefaultRetryInterval 连接失败后首次重试的等待时间，之后每次翻倍
+	DefaultRetryInterval = time.Millisecond * 500
+	// DefaultRetryMaxInterval 连接重试的最大等待时间
+	DefaultRetryMaxInterval = time.Second * 10
+	// TimeFormat 写入时间字段的格式
+	TimeFormat = "2006-01-02 15:04:05"
 )
 
 // Options 连接池参数集
 type Options struct {
+	Name        string
 	Driver      string
 	URI         string
 	Charset     string
 	ParseTime   bool
 	MaxClient   int
 	MaxLifetime time.Duration
+	// MaxIdle 最大空闲连接数，小于0时与MaxClient相同
+	MaxIdle int
+	// MaxIdleTime 连接最大空闲时间，0为不限制，需要Go 1.15及以上
+	MaxIdleTime time.Duration
+	// 连接及读写超时，0为使用驱动默认值
+	Timeout      time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	// TLS mysql的tls参数，如 true、skip-verify 或通过mysql.RegisterTLSConfig注册的名称
+	TLS string
+	// Loc 时区，mysql为loc，postgres为timezone
+	Loc *time.Location
+	// SSLMode postgres的sslmode，如 disable、require、verify-full
+	SSLMode string
+	// SearchPath postgres的search_path
+	SearchPath string
+	// Pragmas sqlite参数，如 _journal_mode=WAL、_foreign_keys=1
+	Pragmas url.Values
+	// Params 其他附加到连接地址的参数
+	Params url.Values
+	// RetryTimeout 首次连接失败时重试的最长时间，0为不重试
+	RetryTimeout     time.Duration
+	RetryInterval    time.Duration
+	RetryMaxInterval time.Duration
+	// HealthCheckInterval 后台健康检查间隔，0为不检查
+	HealthCheckInterval time.Duration
+	// ConnInits 每个新建的物理连接上执行的初始化函数
+	ConnInits []ConnInitFunc
 }
 
 // Option 参数函数
@@ -34,12 +79,17 @@ type Option func(o *Options)
 // NewOptions 返回一个新的参数集
 func NewOptions(opts ...Option) Options {
 	opt := Options{
+		Name:        DefaultName,
 		Driver:      DefaultDriver,
 		URI:         DefaultURI,
 		Charset:     DefaultCharset,
 		ParseTime:   DefaultParseTime,
 		MaxClient:   DefaultMaxClient,
 		MaxLifetime: DefaultMaxLifetime,
+		MaxIdle:     DefaultMaxIdle,
+
+		RetryInterval:    DefaultRetryInterval,
+		RetryMaxInterval: DefaultRetryMaxInterval,
 	}
 
 	for _, o := range opts {
@@ -49,6 +99,13 @@ func NewOptions(opts ...Option) Options {
 	return opt
 }
 
+// Name 设置连接名称
+func Name(v string) Option {
+	return func(o *Options) {
+		o.Name = v
+	}
+}
+
 // Driver 设置数据库引擎
 func Driver(v string) Option {
 	return func(o *Options) {
@@ -90,3 +147,147 @@ func MaxLifetime(d time.Duration) Option {
 		o.MaxLifetime = d
 	}
 }
+
+// MaxIdle 设置最大空闲连接数
+func MaxIdle(v int) Option {
+	return func(o *Options) {
+		o.MaxIdle = v
+	}
+}
+
+// MaxIdleTime 设置连接最大空闲时间
+func MaxIdleTime(d time.Duration) Option {
+	return func(o *Options) {
+		o.MaxIdleTime = d
+	}
+}
+
+// Timeout 设置建立连接的超时时间
+func Timeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = d
+	}
+}
+
+// ReadTimeout 设置读超时时间，仅支持mysql
+func ReadTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.ReadTimeout = d
+	}
+}
+
+// WriteTimeout 设置写超时时间，仅支持mysql
+func WriteTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.WriteTimeout = d
+	}
+}
+
+// TLS 设置mysql的tls参数
+func TLS(v string) Option {
+	return func(o *Options) {
+		o.TLS = v
+	}
+}
+
+// Loc 设置时区
+func Loc(v *time.Location) Option {
+	return func(o *Options) {
+		o.Loc = v
+	}
+}
+
+// SSLMode 设置postgres的sslmode
+func SSLMode(v string) Option {
+	return func(o *Options) {
+		o.SSLMode = v
+	}
+}
+
+// SearchPath 设置postgres的search_path
+func SearchPath(v string) Option {
+	return func(o *Options) {
+		o.SearchPath = v
+	}
+}
+
+// Pragma 设置sqlite参数，name为mattn/go-sqlite3的参数名，如 _journal_mode
+func Pragma(name, value string) Option {
+	return func(o *Options) {
+		o.Pragmas = cloneValues(o.Pragmas)
+		o.Pragmas.Set(name, value)
+	}
+}
+
+// cloneValues 复制参数，避免修改与其他参数集共享的map
+func cloneValues(values url.Values) url.Values {
+	result := make(url.Values, len(values)+1)
+	for k, v := range values {
+		result[k] = append([]string(nil), v...)
+	}
+	return result
+}
+
+// WAL 设置sqlite使用WAL日志模式
+func WAL() Option {
+	return Pragma("_journal_mode", "WAL")
+}
+
+// ForeignKeys 设置sqlite是否启用外键约束
+func ForeignKeys(v bool) Option {
+	return Pragma("_foreign_keys", If(v, "1", "0").(string))
+}
+
+// BusyTimeout 设置sqlite锁等待超时时间
+func BusyTimeout(d time.Duration) Option {
+	return Pragma("_busy_timeout", strconv.FormatInt(int64(d/time.Millisecond), 10))
+}
+
+// Param 设置附加到连接地址的参数
+func Param(name, value string) Option {
+	return func(o *Options) {
+		o.Params = cloneValues(o.Params)
+		o.Params.Set(name, value)
+	}
+}
+
+// Retry 设置首次连接失败时的重试时间，期间按退避间隔重试
+func Retry(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.RetryTimeout = timeout
+	}
+}
+
+// Backoff 设置重试的首次等待时间及最大等待时间，等待时间最小为10ms
+func Backoff(interval, max time.Duration) Option {
+	return func(o *Options) {
+		o.RetryInterval = interval
+		o.RetryMaxInterval = max
+	}
+}
+
+// HealthCheck 设置后台健康检查间隔，通过GetHealthChecker(name)获取状态
+func HealthCheck(interval time.Duration) Option {
+	return func(o *Options) {
+		o.HealthCheckInterval = interval
+	}
+}
+
+// ConnInit 添加新建物理连接时执行的初始化函数
+func ConnInit(f ConnInitFunc) Option {
+	return func(o *Options) {
+		o.ConnInits = append(o.ConnInits, f)
+	}
+}
+
+// InitSQL 添加新建物理连接时执行的sql，如 SET time_zone = '+08:00'
+func InitSQL(stmts ...string) Option {
+	return ConnInit(func(ctx context.Context, conn driver.Conn) error {
+		for _, stmt := range stmts {
+			if err := execConn(ctx, conn, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,13 @@
+module github.com/laoqiu/sqlx-builder/otelsqlxb
+
+go 1.16
+
+require (
+	github.com/laoqiu/sqlx-builder v0.0.0-00010101000000-000000000000
+	github.com/mattn/go-sqlite3 v1.14.6
+	go.opentelemetry.io/otel v1.7.0
+	go.opentelemetry.io/otel/sdk v1.7.0
+	go.opentelemetry.io/otel/trace v1.7.0
+)
+
+replace github.com/laoqiu/sqlx-builder => ../
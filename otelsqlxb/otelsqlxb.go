@@ -0,0 +1,100 @@
+// Package otelsqlxb 为sqlx-builder提供OpenTelemetry tracing，每条执行的sql生成一个span
+package otelsqlxb
+
+import (
+	"context"
+
+	sqlxb "github.com/laoqiu/sqlx-builder"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/laoqiu/sqlx-builder/otelsqlxb"
+
+// Hook 实现sqlxb.QueryHook
+type Hook struct {
+	tracer trace.Tracer
+	attrs  []attribute.KeyValue
+}
+
+// Option 参数函数
+type Option func(h *Hook)
+
+// TracerProvider 设置TracerProvider，默认使用otel全局的TracerProvider
+func TracerProvider(tp trace.TracerProvider) Option {
+	return func(h *Hook) {
+		h.tracer = tp.Tracer(instrumentationName)
+	}
+}
+
+// Attributes 设置附加到每个span的属性
+func Attributes(attrs ...attribute.KeyValue) Option {
+	return func(h *Hook) {
+		h.attrs = append(h.attrs, attrs...)
+	}
+}
+
+// NewHook 返回tracing hook，通过 sqlxb.RegisterQueryHook 或 Builder.QueryHook 注册
+func NewHook(opts ...Option) *Hook {
+	h := &Hook{}
+	for _, o := range opts {
+		o(h)
+	}
+	if h.tracer == nil {
+		h.tracer = otel.GetTracerProvider().Tracer(instrumentationName)
+	}
+	return h
+}
+
+// BeforeQuery 从调用方的context开始span
+func (h *Hook) BeforeQuery(ctx context.Context, e *sqlxb.QueryEvent) context.Context {
+	attrs := append([]attribute.KeyValue{
+		attribute.String("db.system", dbSystem(e.Driver)),
+		attribute.String("db.statement", sqlxb.SanitizeSQL(e.Query)),
+		attribute.String("db.operation", e.Operation),
+	}, h.attrs...)
+	if e.Table != "" {
+		attrs = append(attrs, attribute.String("db.sql.table", e.Table))
+	}
+	ctx, _ = h.tracer.Start(ctx, spanName(e), trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(e.Start), trace.WithAttributes(attrs...))
+	return ctx
+}
+
+// AfterQuery 记录行数及错误并结束span
+func (h *Hook) AfterQuery(ctx context.Context, e *sqlxb.QueryEvent) {
+	span := trace.SpanFromContext(ctx)
+	if e.Rows >= 0 {
+		span.SetAttributes(attribute.Int64("db.rows_affected", e.Rows))
+	}
+	if e.Err != nil {
+		span.RecordError(e.Err)
+		span.SetStatus(codes.Error, e.Err.Error())
+	}
+	span.End(trace.WithTimestamp(e.Start.Add(e.Elapsed)))
+}
+
+// spanName 返回span名称，如 select person
+func spanName(e *sqlxb.QueryEvent) string {
+	if e.Table == "" {
+		return e.Operation
+	}
+	return e.Operation + " " + e.Table
+}
+
+// dbSystem 按OpenTelemetry约定返回db.system
+func dbSystem(driver string) string {
+	switch driver {
+	case "postgres", "pgx":
+		return "postgresql"
+	case "sqlite3", "sqlite":
+		return "sqlite"
+	case "mysql":
+		return "mysql"
+	case "":
+		return "other_sql"
+	}
+	return driver
+}
@@ -0,0 +1,67 @@
+package otelsqlxb
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	sqlxb "github.com/laoqiu/sqlx-builder"
+	_ "github.com/mattn/go-sqlite3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestHook(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	hook := NewHook(TracerProvider(tp))
+
+	db, err := sqlxb.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.MustExec("CREATE TABLE person (id INTEGER PRIMARY KEY, name TEXT)")
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	b := func() *sqlxb.Builder { return sqlxb.NewBuilder(db).WithContext(ctx).QueryHook(hook) }
+	if _, err := b().Table("person").Insert(map[string]interface{}{"id": 1, "name": "tom"}); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	if err := b().Table("person").Pluck("name", &names); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b().Table("missing").Count(); err == nil {
+		t.Fatal("expected error")
+	}
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans))
+	}
+	insert := spans[0]
+	if insert.Name != "insert person" || insert.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("unexpected span: %s parent %v", insert.Name, insert.Parent)
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range insert.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	if attrs["db.system"].AsString() != "sqlite" || attrs["db.sql.table"].AsString() != "person" ||
+		attrs["db.rows_affected"].AsInt64() != 1 || !strings.HasSuffix(attrs["db.statement"].AsString(), "VALUES (?, ?)") {
+		t.Errorf("unexpected attributes: %v", attrs)
+	}
+	attrs = map[attribute.Key]attribute.Value{}
+	for _, kv := range spans[1].Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	if s := attrs["db.statement"].AsString(); s != "SELECT name FROM person" {
+		t.Errorf("unexpected select statement: %q", s)
+	}
+	if failed := spans[2]; failed.Status.Code != codes.Error || len(failed.Events) == 0 {
+		t.Errorf("expected error recorded, got %+v", failed.Status)
+	}
+}
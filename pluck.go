@@ -0,0 +1,54 @@
+package builder
+
+import (
+	"errors"
+	"reflect"
+)
+
+// Pluck 查询单个字段的值到切片，如 []int64、[]string
+func (b *Builder) Pluck(column string, dest interface{}) error {
+	return b.columnBuilder(column).All(dest)
+}
+
+// Value 查询单个字段的单个值
+func (b *Builder) Value(column string, dest interface{}) error {
+	return b.columnBuilder(column).One(dest)
+}
+
+// PluckMap 查询两个字段构建map，dest为 *map[K]V
+func (b *Builder) PluckMap(keyColumn, valueColumn string, dest interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Map {
+		return errors.New("pluck map dest must be a pointer to map")
+	}
+	m := rv.Elem()
+	if m.IsNil() {
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+	query, args, err := b.columnBuilder(keyColumn, valueColumn).BuildQuery()
+	if err != nil {
+		return err
+	}
+	rows, err := b._queryx(query, args)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		k := reflect.New(m.Type().Key())
+		v := reflect.New(m.Type().Elem())
+		if err := rows.Scan(k.Interface(), v.Interface()); err != nil {
+			return err
+		}
+		m.SetMapIndex(k.Elem(), v.Elem())
+	}
+	return rows.Err()
+}
+
+// columnBuilder 返回仅查询指定字段的builder，不修改当前builder
+func (b *Builder) columnBuilder(columns ...string) *Builder {
+	cp := b.Copy()
+	cp.query.Fields = columns
+	cp.preloads = nil
+	return cp
+}
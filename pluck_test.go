@@ -0,0 +1,28 @@
+package builder
+
+import "testing"
+
+func TestPluck(t *testing.T) {
+	db := newTestDB(t,
+		"CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT)",
+		"INSERT INTO city VALUES (1, 'a'), (2, 'b'), (3, 'c')",
+	)
+	var ids []int64
+	if err := NewBuilder(db).Table("city").Where("id > ?", 1).OrderBy("id").Pluck("id", &ids); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != 2 || ids[1] != 3 {
+		t.Errorf("unexpected ids: %v", ids)
+	}
+	var name string
+	if err := NewBuilder(db).Table("city").Where("id = ?", 3).Value("name", &name); err != nil || name != "c" {
+		t.Errorf("unexpected value: %s %v", name, err)
+	}
+	var names map[int64]string
+	if err := NewBuilder(db).Table("city").PluckMap("id", "name", &names); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 3 || names[2] != "b" {
+		t.Errorf("unexpected map: %v", names)
+	}
+}
@@ -0,0 +1,12 @@
+module github.com/laoqiu/sqlx-builder/promsqlxb
+
+go 1.16
+
+require (
+	github.com/jmoiron/sqlx v1.2.0
+	github.com/laoqiu/sqlx-builder v0.0.0-00010101000000-000000000000
+	github.com/mattn/go-sqlite3 v1.14.6
+	github.com/prometheus/client_golang v1.12.2
+)
+
+replace github.com/laoqiu/sqlx-builder => ../
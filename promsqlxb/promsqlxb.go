@@ -0,0 +1,153 @@
+// Package promsqlxb 为sqlx-builder提供Prometheus指标：按操作及表统计的查询数、耗时、错误，以及连接池状态
+package promsqlxb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	sqlxb "github.com/laoqiu/sqlx-builder"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector 实现prometheus.Collector及sqlxb.QueryHook
+type Collector struct {
+	queries  *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+
+	open     *prometheus.Desc
+	inUse    *prometheus.Desc
+	idle     *prometheus.Desc
+	waitCnt  *prometheus.Desc
+	waitTime *prometheus.Desc
+	maxOpen  *prometheus.Desc
+
+	mu  sync.RWMutex
+	dbs map[string]*sql.DB
+}
+
+// Option 参数函数
+type Option func(o *options)
+
+type options struct {
+	namespace string
+	buckets   []float64
+}
+
+// Namespace 设置指标前缀，默认为sqlxb
+func Namespace(v string) Option {
+	return func(o *options) {
+		o.namespace = v
+	}
+}
+
+// Buckets 设置耗时直方图的分桶
+func Buckets(v []float64) Option {
+	return func(o *options) {
+		o.buckets = v
+	}
+}
+
+// NewCollector 返回Collector，需由应用注册到prometheus.Registerer，
+// 并通过 sqlxb.RegisterQueryHook 或 Builder.QueryHook 注册为hook
+func NewCollector(opts ...Option) *Collector {
+	o := options{namespace: "sqlxb", buckets: prometheus.DefBuckets}
+	for _, f := range opts {
+		f(&o)
+	}
+	labels := []string{"operation", "table"}
+	poolDesc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(o.namespace, "pool", name), help, []string{"db"}, nil)
+	}
+	return &Collector{
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace, Name: "queries_total", Help: "Total number of executed statements.",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace, Name: "query_errors_total", Help: "Total number of failed statements by error class.",
+		}, append(labels, "class")),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: o.namespace, Name: "query_duration_seconds", Help: "Statement latency in seconds.", Buckets: o.buckets,
+		}, labels),
+		open:     poolDesc("open_connections", "Number of established connections both in use and idle."),
+		inUse:    poolDesc("in_use_connections", "Number of connections currently in use."),
+		idle:     poolDesc("idle_connections", "Number of idle connections."),
+		waitCnt:  poolDesc("wait_count_total", "Total number of connections waited for."),
+		waitTime: poolDesc("wait_duration_seconds_total", "Total time blocked waiting for a new connection."),
+		maxOpen:  poolDesc("max_open_connections", "Maximum number of open connections to the database."),
+		dbs:      make(map[string]*sql.DB),
+	}
+}
+
+// WatchDB 采集连接池状态，name作为db标签
+func (c *Collector) WatchDB(name string, db *sqlx.DB) {
+	c.mu.Lock()
+	c.dbs[name] = db.DB
+	c.mu.Unlock()
+}
+
+// WatchConnections 采集之后通过 sqlxb.Connect 创建的所有连接池，标签为连接名称
+func (c *Collector) WatchConnections() {
+	sqlxb.OnConnect(c.WatchDB)
+}
+
+// Describe 实现prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.queries.Describe(ch)
+	c.errors.Describe(ch)
+	c.duration.Describe(ch)
+	for _, d := range []*prometheus.Desc{c.open, c.inUse, c.idle, c.waitCnt, c.waitTime, c.maxOpen} {
+		ch <- d
+	}
+}
+
+// Collect 实现prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.queries.Collect(ch)
+	c.errors.Collect(ch)
+	c.duration.Collect(ch)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for name, db := range c.dbs {
+		s := db.Stats()
+		ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(s.OpenConnections), name)
+		ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(s.InUse), name)
+		ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.Idle), name)
+		ch <- prometheus.MustNewConstMetric(c.waitCnt, prometheus.CounterValue, float64(s.WaitCount), name)
+		ch <- prometheus.MustNewConstMetric(c.waitTime, prometheus.CounterValue, s.WaitDuration.Seconds(), name)
+		ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(s.MaxOpenConnections), name)
+	}
+}
+
+// BeforeQuery 实现sqlxb.QueryHook
+func (c *Collector) BeforeQuery(ctx context.Context, e *sqlxb.QueryEvent) context.Context {
+	return ctx
+}
+
+// AfterQuery 实现sqlxb.QueryHook
+func (c *Collector) AfterQuery(ctx context.Context, e *sqlxb.QueryEvent) {
+	c.queries.WithLabelValues(e.Operation, e.Table).Inc()
+	c.duration.WithLabelValues(e.Operation, e.Table).Observe(e.Elapsed.Seconds())
+	if e.Err != nil {
+		c.errors.WithLabelValues(e.Operation, e.Table, ErrorClass(e.Err)).Inc()
+	}
+}
+
+// ErrorClass 返回错误分类: no_rows、timeout、canceled、bad_conn、other
+func ErrorClass(err error) string {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return "no_rows"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone):
+		return "bad_conn"
+	}
+	return "other"
+}
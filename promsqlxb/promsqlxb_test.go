@@ -0,0 +1,58 @@
+package promsqlxb
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	sqlxb "github.com/laoqiu/sqlx-builder"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector()
+	c.WatchConnections()
+	db, err := sqlxb.Connect(sqlxb.Name("main"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.MustExec("CREATE TABLE person (id INTEGER PRIMARY KEY, name TEXT)")
+
+	b := func() *sqlxb.Builder { return sqlxb.NewBuilder(db).QueryHook(c) }
+	if _, err := b().Table("person").Insert(map[string]interface{}{"id": 1, "name": "tom"}); err != nil {
+		t.Fatal(err)
+	}
+	var name string
+	if err := b().Table("person").Where("id = ?", 1).Value("name", &name); err != nil {
+		t.Fatal(err)
+	}
+	if err := b().Table("person").Where("id = ?", 2).Value("name", &name); err != sql.ErrNoRows {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+
+	if n := testutil.ToFloat64(c.queries.WithLabelValues("insert", "person")); n != 1 {
+		t.Errorf("expected 1 insert, got %v", n)
+	}
+	if n := testutil.ToFloat64(c.queries.WithLabelValues("select", "person")); n != 2 {
+		t.Errorf("expected 2 selects, got %v", n)
+	}
+	if n := testutil.ToFloat64(c.errors.WithLabelValues("select", "person", "no_rows")); n != 1 {
+		t.Errorf("expected 1 no_rows error, got %v", n)
+	}
+	expected := `
+# HELP sqlxb_pool_max_open_connections Maximum number of open connections to the database.
+# TYPE sqlxb_pool_max_open_connections gauge
+sqlxb_pool_max_open_connections{db="main"} 10
+# HELP sqlxb_pool_wait_count_total Total number of connections waited for.
+# TYPE sqlxb_pool_wait_count_total counter
+sqlxb_pool_wait_count_total{db="main"} 0
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "sqlxb_pool_max_open_connections", "sqlxb_pool_wait_count_total"); err != nil {
+		t.Error(err)
+	}
+	if ErrorClass(context.DeadlineExceeded) != "timeout" {
+		t.Error("unexpected error class")
+	}
+}
@@ -3,7 +3,9 @@ package builder
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/antlabs/deepcopy"
 	"github.com/jmoiron/sqlx"
@@ -35,19 +37,17 @@ func (b *Builder) Table(tablename string) *Builder {
 
 // Copy 复制
 func (b *Builder) Copy() *Builder {
-	cp := &Builder{
-		db:    b.db,
-		tx:    b.tx,
-		debug: b.debug,
-		query: &Query{},
-	}
+	cp := *b
+	cp.query = &Query{}
+	cp.withoutScopes = append([]string{}, b.withoutScopes...)
+	cp.preloads = append([]string{}, b.preloads...)
 	deepcopy.Copy(cp.query, b.query).Do()
-	return cp
+	return &cp
 }
 
-// Comment 加入sql注释
+// Comment 加入sql注释，注释中的 */ 会被转义以免提前结束注释
 func (b *Builder) Comment(v string) *Builder {
-	b.query.Comment = fmt.Sprintf("/* %s */", v)
+	b.query.Comment = fmt.Sprintf("/* %s */", strings.Replace(v, "*/", "* /", -1))
 	return b
 }
 
@@ -135,51 +135,98 @@ func (b *Builder) Offset(n int) *Builder {
 	return b
 }
 
-func (b *Builder) _parseInsert(data map[string]interface{}) (string, string) {
+// _allowField 判断字段是否写入，租户字段总是写入
+func (b *Builder) _allowField(k string) bool {
+	return len(b.query.Fields) == 0 || indexOf(k, b.query.Fields) != -1 || k == b.tenantColumn()
+}
+
+// formatValue 将值格式化为sql字面量
+func formatValue(v interface{}) string {
+	// 反射找出类型
+	switch val := v.(type) {
+	case nil:
+		return "NULL"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool:
+		return fmt.Sprintf("%v", v)
+	case time.Time:
+		return fmt.Sprintf("'%s'", val.Format(TimeFormat))
+	case *time.Time:
+		if val == nil {
+			return "NULL"
+		}
+		return fmt.Sprintf("'%s'", val.Format(TimeFormat))
+	default:
+		return fmt.Sprintf("'%s'", v)
+	}
+}
+
+func (b *Builder) _parseInsert(data map[string]interface{}) (string, string, []interface{}) {
 	var keystr, valstr string
 	var key, val []string
+	var args []interface{}
+	tenant := b.tenantColumn()
 	for k, v := range data {
-		if len(b.query.Fields) == 0 || indexOf(k, b.query.Fields) != -1 {
+		if b._allowField(k) {
 			key = append(key, "`"+k+"`")
-			// 反射找出类型
-			switch v.(type) {
-			case int, int8, int32, int64, float32, float64, bool:
-				val = append(val, fmt.Sprintf("%v", v))
-			default:
-				val = append(val, fmt.Sprintf("'%s'", v))
+			if k == tenant {
+				val = append(val, "?")
+				args = append(args, v)
+			} else {
+				val = append(val, formatValue(v))
 			}
 		}
 	}
 	keystr = strings.Join(key, ", ")
 	valstr = strings.Join(val, ", ")
-	return keystr, valstr
+	return keystr, valstr, args
 }
 
-func (b *Builder) _parseUpate(data map[string]interface{}) string {
+func (b *Builder) _parseUpate(data map[string]interface{}) (string, []interface{}) {
 	var setstr string
 	var result []string
-
+	var args []interface{}
+	tenant := b.tenantColumn()
 	for k, v := range data {
-		if len(b.query.Fields) == 0 || indexOf(k, b.query.Fields) != -1 {
-			var _value string
-			// 反射找出类型
-			switch v.(type) {
-			case int, int8, int32, int64, float32, float64, bool:
-				_value = fmt.Sprintf("`%s` = %v", k, v)
-			default:
-				_value = fmt.Sprintf("`%s` = '%s'", k, v)
+		if b._allowField(k) {
+			if k == tenant {
+				result = append(result, fmt.Sprintf("`%s` = ?", k))
+				args = append(args, v)
+			} else {
+				result = append(result, fmt.Sprintf("`%s` = %s", k, formatValue(v)))
 			}
-			result = append(result, _value)
 		}
 	}
 
 	setstr = strings.Join(result, ", ")
-	return setstr
+	return setstr, args
 }
 
-func (b *Builder) _parseJoin() (string, []string, error) {
+// _parseUpsert 返回upsert的更新部分，多租户表不更新租户字段，且仅在已有数据属于当前租户时更新
+func (b *Builder) _parseUpsert(data map[string]interface{}) (string, []interface{}) {
+	tenant := b.tenantColumn()
+	if tenant == "" {
+		return b._parseUpate(data)
+	}
+	var result []string
+	var args []interface{}
+	for k := range data {
+		if k == tenant || !b._allowField(k) {
+			continue
+		}
+		result = append(result, fmt.Sprintf("`%s` = IF(`%s` = ?, VALUES(`%s`), `%s`)", k, tenant, k, k))
+		args = append(args, data[tenant])
+	}
+	if len(result) == 0 {
+		// 没有可更新的字段时保持原值
+		result = append(result, fmt.Sprintf("`%s` = `%s`", tenant, tenant))
+	}
+	return strings.Join(result, ", "), args
+}
+
+func (b *Builder) _parseJoin() (string, []string, []interface{}, error) {
 	var result []string
 	var joinTables []string
+	var joinArgs []interface{}
 	for _, join := range b.query.Join {
 		var w string
 		var ok bool
@@ -187,33 +234,99 @@ func (b *Builder) _parseJoin() (string, []string, error) {
 		var sp string
 		sp = join[0].(string)
 		if args, ok = join[1].([]interface{}); !ok {
-			return "", nil, errors.New("join conditions are wrong")
+			return "", nil, nil, errors.New("join conditions are wrong")
 		}
+		var on string
 		switch len(args) {
 		case 1:
 			w = args[0].(string)
 			joinTables = append(joinTables, args[0].(string))
 		case 2:
-			w = fmt.Sprintf("%s ON %s", args[0].(string), args[1].(string))
+			w, on = args[0].(string), args[1].(string)
 			joinTables = append(joinTables, args[0].(string))
 		case 3:
-			w = fmt.Sprintf("%s AS %s ON %s", args[0].(string), args[1].(string), args[2].(string))
+			w, on = fmt.Sprintf("%s AS %s", args[0].(string), args[1].(string)), args[2].(string)
 			joinTables = append(joinTables, args[1].(string))
 		default:
-			return "", nil, errors.New("join format error")
+			return "", nil, nil, errors.New("join format error")
+		}
+		// 关联的多租户表在ON中附加租户条件，LEFT JOIN时不影响主表数据
+		where, err := b.joinTenantWhere(args)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		if where != nil {
+			on = fmt.Sprintf("(%s) AND %s", on, where[1].(string))
+			joinArgs = append(joinArgs, where[2].([]interface{})...)
+		}
+		if on != "" {
+			w += " ON " + on
 		}
 		result = append(result, sp+" "+w)
 	}
-	return strings.Join(result, " "), joinTables, nil
+	return strings.Join(result, " "), joinTables, joinArgs, nil
 }
 
 func (b *Builder) _parseWhere() (string, []interface{}, error) {
+	wherestring, args, err := _parseConditions(b.query.Where)
+	if err != nil {
+		return "", nil, err
+	}
+	// 自动附加的条件(软删除等)
+	conditions, err := b._scopedWhere()
+	if err != nil {
+		return "", nil, err
+	}
+	scoped, scopedArgs, err := _parseConditions(conditions)
+	if err != nil {
+		return "", nil, err
+	}
+	if scoped != "" {
+		if wherestring != "" {
+			wherestring = "(" + wherestring + ") AND " + scoped
+		} else {
+			wherestring = scoped
+		}
+		args = append(args, scopedArgs...)
+	}
+	return wherestring, args, nil
+}
+
+// _scopedWhere 返回builder自动附加的查询条件(软删除、租户)
+func (b *Builder) _scopedWhere() ([][]interface{}, error) {
+	var result [][]interface{}
+	if where := b.softDeleteWhere(); where != "" {
+		result = append(result, []interface{}{"AND", where, []interface{}{}})
+	}
+	where, err := b.tenantWhere()
+	if err != nil {
+		return nil, err
+	}
+	if where != nil {
+		result = append(result, where)
+	}
+	return result, nil
+}
+
+func _parseConditions(conditions [][]interface{}) (string, []interface{}, error) {
 	var result []string
 	var args []interface{}
-	for _, where := range b.query.Where {
+	for _, where := range conditions {
 		var ok bool
 		var wargs []interface{}
 		sp := where[0].(string)
+		// 条件组，整体加括号
+		if group, ok := where[1].([][]interface{}); ok {
+			condition, gargs, err := _parseConditions(group)
+			if err != nil {
+				return "", nil, err
+			}
+			if condition != "" {
+				result = append(result, sp+" ("+condition+")")
+				args = append(args, gargs...)
+			}
+			continue
+		}
 		condition := where[1].(string)
 		if wargs, ok = where[2].([]interface{}); !ok {
 			return "", nil, errors.New("where conditions are wrong")
@@ -243,29 +356,49 @@ func (b *Builder) _parseWhere() (string, []interface{}, error) {
 
 // BuildExec 返回需要执行的sql表达式
 func (b *Builder) BuildExec(method string, data map[string]interface{}) (string, []interface{}, error) {
+	if s := b.scoped(); s != b {
+		return s.BuildExec(method, data)
+	}
 	var sqlstr string
 	var tablename string
 
-	tablename = b.query.Table
+	// mysql 8.0.16之前DELETE不支持表别名，分片时自动附加的条件改为以物理表名限定
+	tablename, err := b.tableName(b.query.Table, method == "UPDATE")
+	if err != nil {
+		return "", nil, err
+	}
+	if method == "DELETE" && tablename != b.query.Table {
+		b = b.Copy()
+		b.qualifier = tablename
+	}
 
 	where, args, err := b._parseWhere()
 	if err != nil {
 		return "", nil, err
 	}
 	where = If(where == "", "", "WHERE "+where).(string)
+	if method != "DELETE" {
+		if data, err = b.withTenant(data, method != "UPDATE"); err != nil {
+			return "", nil, err
+		}
+	}
 
 	switch method {
 	case "INSERT", "INSERT_IGNORE":
-		keystr, valstr := b._parseInsert(data)
+		keystr, valstr, valArgs := b._parseInsert(data)
 		ignore := If(method == "INSERT_IGNORE", "IGNORE", "").(string)
 		sqlstr = fmt.Sprintf("INSERT %s %s INTO %s (%s) VALUES (%s)", b.query.Comment, ignore, tablename, keystr, valstr)
+		// 写入语句不使用查询条件
+		args = valArgs
 	case "INSERT_ON_DUPLICATE_UPDATE":
-		keystr, valstr := b._parseInsert(data)
-		setstr := b._parseUpate(data)
+		keystr, valstr, valArgs := b._parseInsert(data)
+		setstr, setArgs := b._parseUpsert(b.withoutCreated(data))
 		sqlstr = fmt.Sprintf("INSERT %s INTO %s (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s", b.query.Comment, tablename, keystr, valstr, setstr)
+		args = append(valArgs, setArgs...)
 	case "UPDATE":
-		setstr := b._parseUpate(data)
+		setstr, setArgs := b._parseUpate(data)
 		sqlstr = fmt.Sprintf("UPDATE %s %s SET %s %s", b.query.Comment, tablename, setstr, where)
+		args = append(setArgs, args...)
 	case "DELETE":
 		sqlstr = fmt.Sprintf("DELETE %s FROM %s %s", b.query.Comment, tablename, where)
 	}
@@ -273,12 +406,56 @@ func (b *Builder) BuildExec(method string, data map[string]interface{}) (string,
 	return sqlstr, args, nil
 }
 
+// BuildBatchInsert 返回批量插入的sql表达式及参数，字段取所有数据的并集
+func (b *Builder) BuildBatchInsert(rows []map[string]interface{}) (string, []interface{}, error) {
+	if len(rows) == 0 {
+		return "", nil, errors.New("batch insert data is empty")
+	}
+	for i, row := range rows {
+		row, err := b.withTenant(row, true)
+		if err != nil {
+			return "", nil, err
+		}
+		rows[i] = row
+	}
+	var columns []string
+	for _, row := range rows {
+		for k := range row {
+			if indexOf(k, columns) == -1 && b._allowField(k) {
+				columns = append(columns, k)
+			}
+		}
+	}
+	sort.Strings(columns)
+	tablename, err := b.tableName(b.query.Table, false)
+	if err != nil {
+		return "", nil, err
+	}
+	var key, values []string
+	var args []interface{}
+	for _, k := range columns {
+		key = append(key, "`"+k+"`")
+	}
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	for _, row := range rows {
+		for _, k := range columns {
+			args = append(args, row[k])
+		}
+		values = append(values, placeholders)
+	}
+	sqlstr := fmt.Sprintf("INSERT %s INTO %s (%s) VALUES %s", b.query.Comment, tablename, strings.Join(key, ", "), strings.Join(values, ", "))
+	return sqlstr, args, nil
+}
+
 // BuildQuery 合并query表达式
 func (b *Builder) BuildQuery() (string, []interface{}, error) {
+	if s := b.scoped(); s != b {
+		return s.BuildQuery()
+	}
 	// table
 	table := DefaultMapper(b.query.Table)
 	// join
-	join, joinTables, err := b._parseJoin()
+	join, joinTables, joinArgs, err := b._parseJoin()
 	if err != nil {
 		return "", nil, err
 	}
@@ -296,11 +473,16 @@ func (b *Builder) BuildQuery() (string, []interface{}, error) {
 		allFields = strings.Join(_t, ", ")
 	}
 	fields := If(len(b.query.Fields) == 0, allFields, strings.Join(b.query.Fields, ",")).(string)
+	from, err := b.tableName(table, true)
+	if err != nil {
+		return "", nil, err
+	}
 	// where
 	where, args, err := b._parseWhere()
 	if err != nil {
 		return "", nil, err
 	}
+	args = append(joinArgs, args...)
 	// where
 	where = If(where == "", "", "WHERE "+where).(string)
 	// group
@@ -315,7 +497,7 @@ func (b *Builder) BuildQuery() (string, []interface{}, error) {
 	offset := If(b.query.Offset == 0, "", fmt.Sprintf("OFFSET %d", b.query.Offset)).(string)
 	// 组合
 	sqlstr := strings.Join(Filter([]string{
-		"SELECT", b.query.Comment, distinct, fields, "FROM", table, join, where, group, having, order, limit, offset, b.query.Lock},
+		"SELECT", b.query.Comment, distinct, fields, "FROM", from, join, where, group, having, order, limit, offset, b.query.Lock},
 		func(x string) bool { return x != "" }), " ")
 	return sqlstr, args, nil
 }
@@ -0,0 +1,105 @@
+package builder
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueryEvent 一次sql执行的信息，Query及Args已隐藏敏感字段
+type QueryEvent struct {
+	// Driver 数据库引擎，如 mysql、sqlite3
+	Driver string
+	// Operation 操作类型: select、insert、update、delete等
+	Operation string
+	Table     string
+	Query     string
+	Args      []interface{}
+	Start     time.Time
+	Elapsed   time.Duration
+	// Rows 查询返回或执行影响的行数，未知时为-1
+	Rows int64
+	Err  error
+}
+
+// QueryHook sql执行前后调用，用于tracing、metrics等；日志也基于同一调用点
+type QueryHook interface {
+	// BeforeQuery 执行前调用，返回的context用于执行sql及AfterQuery
+	BeforeQuery(ctx context.Context, e *QueryEvent) context.Context
+	// AfterQuery 执行后调用，e中已填充耗时、行数及错误
+	AfterQuery(ctx context.Context, e *QueryEvent)
+}
+
+var queryHooks = struct {
+	sync.RWMutex
+	hs []QueryHook
+}{}
+
+// RegisterQueryHook 注册全局QueryHook，对所有builder生效
+func RegisterQueryHook(h QueryHook) {
+	queryHooks.Lock()
+	queryHooks.hs = append(queryHooks.hs, h)
+	queryHooks.Unlock()
+}
+
+// QueryHook 为当前builder添加QueryHook，在全局hook之后调用
+func (b *Builder) QueryHook(hs ...QueryHook) *Builder {
+	b.queryHooks = append(append([]QueryHook{}, b.queryHooks...), hs...)
+	return b
+}
+
+// hooks 返回当前builder生效的全部QueryHook
+func (b *Builder) hooks() []QueryHook {
+	queryHooks.RLock()
+	defer queryHooks.RUnlock()
+	if len(queryHooks.hs) == 0 {
+		return b.queryHooks
+	}
+	return append(append([]QueryHook{}, queryHooks.hs...), b.queryHooks...)
+}
+
+// beforeQuery 创建执行事件并调用BeforeQuery
+func (b *Builder) beforeQuery(ctx context.Context, stmt *Statement, query string, args []interface{}) (context.Context, *QueryEvent) {
+	e := &QueryEvent{
+		Driver:    b.driverName(),
+		Operation: stmt.Operation,
+		Table:     stmt.Table,
+		Query:     query,
+		Args:      args,
+		Start:     time.Now(),
+	}
+	for _, h := range b.hooks() {
+		ctx = h.BeforeQuery(ctx, e)
+	}
+	return ctx, e
+}
+
+// afterQuery 记录执行结果，输出日志并倒序调用AfterQuery
+func (b *Builder) afterQuery(ctx context.Context, e *QueryEvent, rows int64, err error) {
+	e.Elapsed = time.Since(e.Start)
+	e.Rows = rows
+	e.Err = err
+	b.logQuery(ctx, e)
+	hs := b.hooks()
+	for i := len(hs) - 1; i >= 0; i-- {
+		hs[i].AfterQuery(ctx, e)
+	}
+}
+
+// Operation 返回sql语句的操作类型(小写)，如 select、insert
+func Operation(query string) string {
+	query = strings.TrimSpace(query)
+	// 跳过开头的注释
+	for strings.HasPrefix(query, "/*") {
+		end := strings.Index(query, "*/")
+		if end < 0 {
+			break
+		}
+		query = strings.TrimSpace(query[end+2:])
+	}
+	if i := strings.IndexAny(query, " \t\n("); i > 0 {
+		query = query[:i]
+	}
+	return strings.ToLower(query)
+}
@@ -0,0 +1,331 @@
+package builder
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// 关联关系类型，在struct tag中声明:
+//
+//	Addresses []Address `db:"-" json:"-" sqlxb:"has_many,foreign_key:person_id"`
+//	Profile *Profile `db:"-" json:"-" sqlxb:"has_one,foreign_key:person_id"`
+//	Company *Company `db:"-" json:"-" sqlxb:"belongs_to,foreign_key:company_id"`
+//	Tags []Tag `db:"-" json:"-" sqlxb:"many_to_many,join_table:person_tag,foreign_key:person_id,association_key:tag_id"`
+//
+// 可选参数: table 关联表名，默认为类型名经DefaultMapper转换; references 被引用字段，默认为id
+const (
+	HasOne     = "has_one"
+	HasMany    = "has_many"
+	BelongsTo  = "belongs_to"
+	ManyToMany = "many_to_many"
+)
+
+// relation 关联关系定义
+type relation struct {
+	kind           string
+	field          reflect.StructField
+	elem           reflect.Type // 关联数据的struct类型
+	table          string
+	foreignKey     string
+	references     string
+	joinTable      string
+	associationKey string
+}
+
+// isRelation 判断字段是否声明了关联关系
+func isRelation(tags map[string]string) bool {
+	for _, kind := range []string{HasOne, HasMany, BelongsTo, ManyToMany} {
+		if _, ok := tags[kind]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRelation 读取struct中名为name的关联关系
+func parseRelation(t reflect.Type, name string) (*relation, error) {
+	f, ok := t.FieldByName(name)
+	if !ok {
+		return nil, fmt.Errorf("relation %s not found in %s", name, t.Name())
+	}
+	tags := parseTag(f.Tag.Get(TagName))
+	r := &relation{field: f, references: "id"}
+	for _, kind := range []string{HasOne, HasMany, BelongsTo, ManyToMany} {
+		if _, ok := tags[kind]; ok {
+			r.kind = kind
+		}
+	}
+	if r.kind == "" {
+		return nil, fmt.Errorf("field %s of %s is not a relation", name, t.Name())
+	}
+	r.elem = f.Type
+	for r.elem.Kind() == reflect.Ptr || r.elem.Kind() == reflect.Slice {
+		r.elem = r.elem.Elem()
+	}
+	if r.elem.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("relation %s of %s must be a struct", name, t.Name())
+	}
+	r.table = If(tags["table"] != "", tags["table"], DefaultMapper(r.elem.Name())).(string)
+	if v := tags["references"]; v != "" {
+		r.references = v
+	}
+	switch r.kind {
+	case BelongsTo:
+		r.foreignKey = If(tags["foreign_key"] != "", tags["foreign_key"], DefaultMapper(name)+"_id").(string)
+	case ManyToMany:
+		r.foreignKey = If(tags["foreign_key"] != "", tags["foreign_key"], DefaultMapper(t.Name())+"_id").(string)
+		r.associationKey = If(tags["association_key"] != "", tags["association_key"], DefaultMapper(r.elem.Name())+"_id").(string)
+		r.joinTable = tags["join_table"]
+		if r.joinTable == "" {
+			return nil, fmt.Errorf("relation %s of %s requires join_table", name, t.Name())
+		}
+	default:
+		r.foreignKey = If(tags["foreign_key"] != "", tags["foreign_key"], DefaultMapper(t.Name())+"_id").(string)
+	}
+	return r, nil
+}
+
+// Preload 预加载关联数据，One/All后每个关联执行一次 IN 查询，支持嵌套如 "Orders.Items"
+func (b *Builder) Preload(relations ...string) *Builder {
+	b.preloads = append(b.preloads, relations...)
+	return b
+}
+
+// preload 加载dest的所有预加载关联
+func (b *Builder) preload(dest interface{}) error {
+	if len(b.preloads) == 0 {
+		return nil
+	}
+	parents := collectStructs(reflect.ValueOf(dest))
+	for _, path := range b.preloads {
+		if err := b.loadRelation(parents, strings.Split(path, ".")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relatedBuilder 返回查询关联表的builder，继承连接、事务、context及租户设置
+func (b *Builder) relatedBuilder(table string) *Builder {
+	cp := b.Copy()
+	cp.Table(table)
+	cp.preloads = nil
+	cp.shard = nil
+	cp.trashed = trashedDefault
+	cp.withoutScopes = nil
+	cp.withoutAllScopes = false
+	cp.scopesApplied = false
+	return cp
+}
+
+// loadRelation 为parents加载path指定的关联
+func (b *Builder) loadRelation(parents []reflect.Value, path []string) error {
+	if len(parents) == 0 {
+		return nil
+	}
+	r, err := parseRelation(parents[0].Type(), path[0])
+	if err != nil {
+		return err
+	}
+
+	var parentColumn, childColumn string
+	switch r.kind {
+	case BelongsTo:
+		parentColumn, childColumn = r.foreignKey, r.references
+	case ManyToMany:
+		parentColumn, childColumn = r.references, "id"
+	default:
+		parentColumn, childColumn = r.references, r.foreignKey
+	}
+	if !hasColumn(parents[0].Type(), parentColumn) {
+		return fmt.Errorf("column %s not found in %s", parentColumn, parents[0].Type().Name())
+	}
+	if !hasColumn(r.elem, childColumn) {
+		return fmt.Errorf("column %s not found in %s", childColumn, r.elem.Name())
+	}
+	keys := columnValues(parents, parentColumn)
+
+	// 多对多先查询中间表
+	var pairs map[string][]string
+	if r.kind == ManyToMany && len(keys) > 0 {
+		var rows []struct {
+			Parent interface{} `db:"parent"`
+			Child  interface{} `db:"child"`
+		}
+		err := b.relatedBuilder(r.joinTable).
+			Fields(r.foreignKey+" AS parent", r.associationKey+" AS child").
+			Where(r.foreignKey+" IN (?)", keys).All(&rows)
+		if err != nil {
+			return err
+		}
+		pairs = make(map[string][]string)
+		keys = nil
+		seen := make(map[string]bool)
+		for _, row := range rows {
+			p, c := keyString(row.Parent), keyString(row.Child)
+			pairs[p] = append(pairs[p], c)
+			if !seen[c] {
+				seen[c] = true
+				keys = append(keys, row.Child)
+			}
+		}
+	}
+
+	children := reflect.New(reflect.SliceOf(r.elem))
+	if len(keys) > 0 {
+		if err := b.relatedBuilder(r.table).Where(childColumn+" IN (?)", keys).All(children.Interface()); err != nil {
+			return err
+		}
+	}
+	items := collectStructs(children)
+	// 先加载嵌套关联，再赋值给父级
+	if len(path) > 1 {
+		if err := b.loadRelation(items, path[1:]); err != nil {
+			return err
+		}
+	}
+
+	grouped := make(map[string][]reflect.Value)
+	for _, item := range items {
+		if k := keyString(fieldByColumn(item, childColumn).Interface()); k != "" {
+			grouped[k] = append(grouped[k], item)
+		}
+	}
+	for _, parent := range parents {
+		var matched []reflect.Value
+		k := keyString(fieldByColumn(parent, parentColumn).Interface())
+		if k != "" {
+			matched = grouped[k]
+		}
+		if r.kind == ManyToMany {
+			matched = nil
+			for _, c := range pairs[k] {
+				matched = append(matched, grouped[c]...)
+			}
+		}
+		assignRelation(parent.FieldByIndex(r.field.Index), matched)
+	}
+	return nil
+}
+
+// assignRelation 将关联数据赋值给字段，字段可以是T、*T、[]T或[]*T
+func assignRelation(field reflect.Value, items []reflect.Value) {
+	t := field.Type()
+	if t.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(t, 0, len(items))
+		for _, item := range items {
+			if t.Elem().Kind() == reflect.Ptr {
+				slice = reflect.Append(slice, item.Addr())
+			} else {
+				slice = reflect.Append(slice, item)
+			}
+		}
+		field.Set(slice)
+		return
+	}
+	if len(items) == 0 {
+		field.Set(reflect.Zero(t))
+		return
+	}
+	if t.Kind() == reflect.Ptr {
+		field.Set(items[0].Addr())
+	} else {
+		field.Set(items[0])
+	}
+}
+
+// collectStructs 返回v中所有可寻址的struct，v可以是*T、*[]T或*[]*T
+func collectStructs(v reflect.Value) []reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return []reflect.Value{v}
+	case reflect.Slice:
+		var result []reflect.Value
+		for i := 0; i < v.Len(); i++ {
+			result = append(result, collectStructs(v.Index(i).Addr())...)
+		}
+		return result
+	}
+	return nil
+}
+
+// hasColumn 判断struct是否有对应数据表字段
+func hasColumn(t reflect.Type, column string) bool {
+	for _, f := range structFields(t) {
+		if f.column == column {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldByColumn 返回struct中对应数据表字段的值
+func fieldByColumn(v reflect.Value, column string) reflect.Value {
+	for _, f := range structFields(v.Type()) {
+		if f.column == column {
+			return v.FieldByIndex(f.field.Index)
+		}
+	}
+	return reflect.ValueOf(nil)
+}
+
+// columnValues 返回去重后的字段值，忽略NULL
+func columnValues(items []reflect.Value, column string) []interface{} {
+	var result []interface{}
+	seen := make(map[string]bool)
+	for _, item := range items {
+		v, ok := keyValue(fieldByColumn(item, column).Interface())
+		if !ok {
+			continue
+		}
+		k := keyString(v)
+		if !seen[k] {
+			seen[k] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// keyValue 返回关联键的实际值，解引用指针并转换sql.Null*等driver.Valuer，NULL返回false
+func keyValue(v interface{}) (interface{}, bool) {
+	rv := reflect.ValueOf(v)
+	for rv.IsValid() && rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, false
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil, false
+	}
+	v = rv.Interface()
+	if valuer, ok := v.(driver.Valuer); ok {
+		val, err := valuer.Value()
+		if err != nil || val == nil {
+			return nil, false
+		}
+		v = val
+	}
+	return v, true
+}
+
+// keyString 将关联键转为字符串用于匹配，兼容不同的整数类型、指针、sql.Null*及[]byte，NULL返回空
+func keyString(v interface{}) string {
+	v, ok := keyValue(v)
+	if !ok {
+		return ""
+	}
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
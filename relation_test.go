@@ -0,0 +1,117 @@
+package builder
+
+import (
+	"database/sql"
+	"testing"
+)
+
+type testCompany struct {
+	ID   int64  `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
+}
+
+type testItemRow struct {
+	ID      int64  `db:"id" json:"id"`
+	OrderID int64  `db:"order_id" json:"order_id"`
+	Name    string `db:"name" json:"name"`
+}
+
+type testPersonOrder struct {
+	ID       int64         `db:"id" json:"id"`
+	PersonID int64         `db:"person_id" json:"person_id"`
+	Items    []testItemRow `db:"-" json:"-" sqlxb:"has_many,table:item,foreign_key:order_id"`
+}
+
+type testTag struct {
+	ID   int64  `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
+}
+
+type testPerson struct {
+	ID        int64              `db:"id" json:"id"`
+	CompanyID int64              `db:"company_id" json:"company_id"`
+	Company   *testCompany       `db:"-" json:"-" sqlxb:"belongs_to,table:company"`
+	Orders    []*testPersonOrder `db:"-" json:"-" sqlxb:"has_many,table:orders,foreign_key:person_id"`
+	Tags      []testTag          `db:"-" json:"-" sqlxb:"many_to_many,table:tag,join_table:person_tag,foreign_key:person_id,association_key:tag_id"`
+}
+
+func TestPreload(t *testing.T) {
+	db := newTestDB(t,
+		"CREATE TABLE company (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE person (id INTEGER PRIMARY KEY, company_id INTEGER)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, person_id INTEGER)",
+		"CREATE TABLE item (id INTEGER PRIMARY KEY, order_id INTEGER, name TEXT)",
+		"CREATE TABLE tag (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE person_tag (person_id INTEGER, tag_id INTEGER)",
+		"INSERT INTO company VALUES (1, 'acme')",
+		"INSERT INTO person VALUES (1, 1), (2, 0)",
+		"INSERT INTO orders VALUES (10, 1), (11, 1), (12, 2)",
+		"INSERT INTO item VALUES (100, 10, 'a'), (101, 10, 'b'), (102, 12, 'c')",
+		"INSERT INTO tag VALUES (1, 'x'), (2, 'y')",
+		"INSERT INTO person_tag VALUES (1, 1), (1, 2), (2, 2)",
+	)
+
+	var persons []testPerson
+	err := NewBuilder(db).Table("person").OrderBy("id").Preload("Company", "Orders.Items", "Tags").All(&persons)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(persons) != 2 {
+		t.Fatalf("expected 2 persons, got %d", len(persons))
+	}
+	p1, p2 := persons[0], persons[1]
+	if p1.Company == nil || p1.Company.Name != "acme" || p2.Company != nil {
+		t.Errorf("belongs_to not loaded: %+v %+v", p1.Company, p2.Company)
+	}
+	if len(p1.Orders) != 2 || len(p2.Orders) != 1 {
+		t.Fatalf("has_many not loaded: %d %d", len(p1.Orders), len(p2.Orders))
+	}
+	if len(p2.Orders[0].Items) != 1 || p2.Orders[0].Items[0].Name != "c" {
+		t.Errorf("nested relation not loaded: %+v", p2.Orders[0])
+	}
+	if len(p1.Tags) != 2 || len(p2.Tags) != 1 || p2.Tags[0].Name != "y" {
+		t.Errorf("many_to_many not loaded: %+v %+v", p1.Tags, p2.Tags)
+	}
+
+	one := &testPerson{}
+	if err := NewBuilder(db).Table("person").Where("id = ?", 2).Preload("Orders").One(one); err != nil {
+		t.Fatal(err)
+	}
+	if len(one.Orders) != 1 || one.Orders[0].ID != 12 {
+		t.Errorf("preload after One failed: %+v", one.Orders)
+	}
+	if err := NewBuilder(db).Table("person").Preload("Unknown").All(&persons); err == nil {
+		t.Error("expected error for unknown relation")
+	}
+}
+
+type testNullablePerson struct {
+	ID        int64         `db:"id" json:"id"`
+	CompanyID *int64        `db:"company_id" json:"company_id"`
+	OwnerID   sql.NullInt64 `db:"owner_id" json:"owner_id"`
+	Company   *testCompany  `db:"-" json:"-" sqlxb:"belongs_to,table:company"`
+	Owner     *testCompany  `db:"-" json:"-" sqlxb:"belongs_to,table:company,foreign_key:owner_id"`
+}
+
+func TestPreloadNullableKey(t *testing.T) {
+	db := newTestDB(t,
+		"CREATE TABLE company (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE person (id INTEGER PRIMARY KEY, company_id INTEGER NULL, owner_id INTEGER NULL)",
+		"INSERT INTO company VALUES (1, 'acme'), (2, 'initech')",
+		"INSERT INTO person VALUES (1, 1, 2), (2, NULL, NULL)",
+	)
+	var persons []testNullablePerson
+	if err := NewBuilder(db).Table("person").OrderBy("id").Preload("Company", "Owner").All(&persons); err != nil {
+		t.Fatal(err)
+	}
+	if len(persons) != 2 {
+		t.Fatalf("expected 2 persons, got %d", len(persons))
+	}
+	p1, p2 := persons[0], persons[1]
+	if p1.Company == nil || p1.Company.Name != "acme" || p1.Owner == nil || p1.Owner.Name != "initech" {
+		t.Errorf("nullable key not matched: %+v %+v", p1.Company, p1.Owner)
+	}
+	if p2.Company != nil || p2.Owner != nil {
+		t.Errorf("expected no relation for NULL key: %+v %+v", p2.Company, p2.Owner)
+	}
+}
@@ -0,0 +1,98 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Rows 逐行读取查询结果的迭代器，使用完毕必须调用Close
+type Rows struct {
+	*sqlx.Rows
+	ctx context.Context
+	err error
+}
+
+// Next 读取下一行，context取消时返回false并由Err返回取消原因
+func (r *Rows) Next() bool {
+	if r.err != nil {
+		return false
+	}
+	if err := r.ctx.Err(); err != nil {
+		r.err = err
+		return false
+	}
+	return r.Rows.Next()
+}
+
+// Scan 将当前行赋值给dest，单个struct指针时按字段名赋值并调用AfterFind钩子，
+// 其他情况(基本类型、sql.Scanner或多个dest)按列顺序赋值
+func (r *Rows) Scan(dest ...interface{}) error {
+	if len(dest) != 1 || !isStructDest(dest[0]) {
+		return r.Rows.Scan(dest...)
+	}
+	if err := r.Rows.StructScan(dest[0]); err != nil {
+		return err
+	}
+	return callAfterFind(dest[0])
+}
+
+// isStructDest 判断是否为按字段名赋值的struct指针，实现sql.Scanner的类型及time.Time除外
+func isStructDest(dest interface{}) bool {
+	t := reflect.TypeOf(dest)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	return t.Elem() != timeType && !t.Implements(scannerType)
+}
+
+// Err 返回迭代过程中的错误
+func (r *Rows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.Rows.Err()
+}
+
+// Rows 执行查询并返回逐行读取的迭代器
+func (b *Builder) Rows() (*Rows, error) {
+	query, args, err := b.BuildQuery()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := b._queryx(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &Rows{Rows: rows, ctx: b.context()}, nil
+}
+
+// Each 逐行读取查询结果并调用fn，fn的格式为 func(*T) error，
+// fn返回错误时停止读取并返回该错误，结束后总是关闭rows
+func (b *Builder) Each(fn interface{}) error {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() != 1 || ft.In(0).Kind() != reflect.Ptr ||
+		ft.NumOut() != 1 || ft.Out(0) != errorType {
+		return errors.New("each callback must be func(*T) error")
+	}
+	rows, err := b.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		dest := reflect.New(ft.In(0).Elem())
+		if err := rows.Scan(dest.Interface()); err != nil {
+			return err
+		}
+		if out := fv.Call([]reflect.Value{dest})[0]; !out.IsNil() {
+			return out.Interface().(error)
+		}
+	}
+	return rows.Err()
+}
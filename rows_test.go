@@ -0,0 +1,81 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+type testLog struct {
+	ID  int64  `db:"id"`
+	Msg string `db:"msg"`
+}
+
+func TestEach(t *testing.T) {
+	db := newTestDB(t,
+		"CREATE TABLE log (id INTEGER PRIMARY KEY, msg TEXT)",
+		"INSERT INTO log VALUES (1, 'a'), (2, 'b'), (3, 'c')",
+	)
+	var msgs []string
+	err := NewBuilder(db).Table("log").OrderBy("id").Each(func(l *testLog) error {
+		msgs = append(msgs, l.Msg)
+		return nil
+	})
+	if err != nil || len(msgs) != 3 {
+		t.Fatalf("unexpected result: %v %v", msgs, err)
+	}
+
+	// 基本类型按列赋值
+	var ids []int64
+	err = NewBuilder(db).Table("log").Fields("id").OrderBy("id").Each(func(id *int64) error {
+		ids = append(ids, *id)
+		return nil
+	})
+	if err != nil || len(ids) != 3 || ids[2] != 3 {
+		t.Errorf("unexpected ids: %v %v", ids, err)
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err = NewBuilder(db).Table("log").Each(func(l *testLog) error {
+		n++
+		return stop
+	})
+	if err != stop || n != 1 {
+		t.Errorf("expected stop after first row, got %d %v", n, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rows, err := NewBuilder(db).WithContext(ctx).Table("log").Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatal("expected first row")
+	}
+	cancel()
+	if rows.Next() || rows.Err() != context.Canceled {
+		t.Errorf("expected context canceled, got %v", rows.Err())
+	}
+
+	if err := NewBuilder(db).Table("log").Each(func(l testLog) {}); err == nil {
+		t.Error("expected invalid callback error")
+	}
+	// 迭代结束后连接已释放
+	if _, err := NewBuilder(db).Table("log").Count(); err != nil {
+		t.Error(err)
+	}
+
+	rows, err = NewBuilder(db).Table("log").Fields("id", "msg").Where("id = ?", 2).Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var id int64
+	var msg sql.NullString
+	if !rows.Next() || rows.Scan(&id, &msg) != nil || id != 2 || msg.String != "b" {
+		t.Errorf("unexpected columns: %d %v %v", id, msg, rows.Err())
+	}
+}
@@ -0,0 +1,572 @@
+package builder
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 数据库方言
+const (
+	DialectMySQL    = "mysql"
+	DialectPostgres = "postgres"
+	DialectSQLite   = "sqlite"
+)
+
+// dialectOf 按驱动名返回方言，未知驱动按mysql处理
+func dialectOf(driverName string) string {
+	switch driverName {
+	case "postgres", "pgx":
+		return DialectPostgres
+	case "sqlite3", "sqlite":
+		return DialectSQLite
+	}
+	return DialectMySQL
+}
+
+// SchemaBuilder 按数据库方言生成并执行DDL
+type SchemaBuilder struct {
+	b       *Builder
+	dialect string
+}
+
+// Schema 返回db的SchemaBuilder，集群使用主库
+func Schema(db *sqlx.DB) *SchemaBuilder {
+	return NewBuilder(db).Schema()
+}
+
+// Schema 返回使用当前builder执行DDL的SchemaBuilder，继承事务、context及拦截器，可在迁移函数中使用
+func (b *Builder) Schema() *SchemaBuilder {
+	return &SchemaBuilder{b: b, dialect: dialectOf(b.driverName())}
+}
+
+// Dialect 返回方言
+func (s *SchemaBuilder) Dialect() string {
+	return s.dialect
+}
+
+// CreateTable 创建数据表
+func (s *SchemaBuilder) CreateTable(name string, f func(t *Table)) error {
+	t := s.table(name, f)
+	return s.exec(t.createSQL(false))
+}
+
+// CreateTableIfNotExists 数据表不存在时创建，已存在时跳过建表及建索引(mysql不支持 CREATE INDEX IF NOT EXISTS)
+func (s *SchemaBuilder) CreateTableIfNotExists(name string, f func(t *Table)) error {
+	exists, err := s.HasTable(name)
+	if err != nil || exists {
+		return err
+	}
+	t := s.table(name, f)
+	return s.exec(t.createSQL(true))
+}
+
+// AlterTable 修改数据表，支持添加、删除、重命名字段及添加、删除索引
+func (s *SchemaBuilder) AlterTable(name string, f func(t *Table)) error {
+	t := s.table(name, f)
+	stmts, err := t.alterSQL()
+	if err != nil {
+		return err
+	}
+	return s.exec(stmts)
+}
+
+// DropTable 删除数据表
+func (s *SchemaBuilder) DropTable(name string) error {
+	return s.exec([]string{"DROP TABLE " + s.quote(name)})
+}
+
+// DropTableIfExists 数据表存在时删除
+func (s *SchemaBuilder) DropTableIfExists(name string) error {
+	return s.exec([]string{"DROP TABLE IF EXISTS " + s.quote(name)})
+}
+
+// RenameTable 重命名数据表
+func (s *SchemaBuilder) RenameTable(from, to string) error {
+	return s.exec([]string{fmt.Sprintf("ALTER TABLE %s RENAME TO %s", s.quote(from), s.quote(to))})
+}
+
+// AddIndex 添加索引，索引名为 idx_表名_字段名
+func (s *SchemaBuilder) AddIndex(table string, columns ...string) error {
+	return s.AlterTable(table, func(t *Table) { t.Index(columns...) })
+}
+
+// AddUniqueIndex 添加唯一索引，索引名为 uniq_表名_字段名
+func (s *SchemaBuilder) AddUniqueIndex(table string, columns ...string) error {
+	return s.AlterTable(table, func(t *Table) { t.UniqueIndex(columns...) })
+}
+
+// DropIndex 删除索引
+func (s *SchemaBuilder) DropIndex(table, name string) error {
+	return s.AlterTable(table, func(t *Table) { t.DropIndex(name) })
+}
+
+// HasTable 判断数据表是否存在
+func (s *SchemaBuilder) HasTable(name string) (bool, error) {
+	var query string
+	switch s.dialect {
+	case DialectSQLite:
+		query = "SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?"
+	case DialectPostgres:
+		query = "SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1"
+	default:
+		query = "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?"
+	}
+	names, err := s.names(query, name)
+	return len(names) > 0, err
+}
+
+// Columns 返回数据表的字段名
+func (s *SchemaBuilder) Columns(table string) ([]string, error) {
+	var query string
+	switch s.dialect {
+	case DialectSQLite:
+		query = "SELECT name FROM pragma_table_info(?)"
+	case DialectPostgres:
+		query = "SELECT column_name FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1"
+	default:
+		query = "SELECT column_name FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ?"
+	}
+	return s.names(query, table)
+}
+
+// Indexes 返回数据表的索引名
+func (s *SchemaBuilder) Indexes(table string) ([]string, error) {
+	var query string
+	switch s.dialect {
+	case DialectSQLite:
+		query = "SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ?"
+	case DialectPostgres:
+		query = "SELECT indexname FROM pg_indexes WHERE schemaname = current_schema() AND tablename = $1"
+	default:
+		query = "SELECT DISTINCT index_name FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ?"
+	}
+	return s.names(query, table)
+}
+
+// names 查询名称列表
+func (s *SchemaBuilder) names(query string, args ...interface{}) ([]string, error) {
+	var names []string
+	err := s.b._select(&names, query, args)
+	return names, err
+}
+
+func (s *SchemaBuilder) table(name string, f func(t *Table)) *Table {
+	t := &Table{name: name, dialect: s.dialect}
+	f(t)
+	return t
+}
+
+func (s *SchemaBuilder) quote(name string) string {
+	return quoteIdent(s.dialect, name)
+}
+
+// exec 依次执行语句，mysql的DDL会隐式提交事务
+func (s *SchemaBuilder) exec(stmts []string) error {
+	for _, stmt := range stmts {
+		if _, err := s.b.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quoteIdent 按方言转义标识符
+func quoteIdent(dialect, name string) string {
+	if dialect == DialectMySQL {
+		return "`" + strings.Replace(name, "`", "``", -1) + "`"
+	}
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+// Table CreateTable及AlterTable中的数据表定义
+type Table struct {
+	name    string
+	dialect string
+	columns []*Column
+	indexes []*tableIndex
+	primary []string
+	// AlterTable时的删除及重命名操作
+	dropColumns   []string
+	renameColumns [][2]string
+	dropIndexes   []string
+}
+
+// tableIndex 索引定义
+type tableIndex struct {
+	name    string
+	columns []string
+	unique  bool
+}
+
+// Column 字段定义
+type Column struct {
+	name          string
+	kind          string
+	size          int
+	precision     int
+	scale         int
+	nullable      bool
+	unsigned      bool
+	autoIncrement bool
+	primary       bool
+	unique        bool
+	defaultValue  interface{}
+	defaultRaw    string
+	hasDefault    bool
+}
+
+// 字段类型
+const (
+	ColumnIncrements    = "increments"
+	ColumnBigIncrements = "big_increments"
+	ColumnString        = "string"
+	ColumnText          = "text"
+	ColumnSmallInteger  = "small_integer"
+	ColumnInteger       = "integer"
+	ColumnBigInteger    = "big_integer"
+	ColumnBoolean       = "boolean"
+	ColumnFloat         = "float"
+	ColumnDouble        = "double"
+	ColumnDecimal       = "decimal"
+	ColumnDate          = "date"
+	ColumnDateTime      = "datetime"
+	ColumnTimestamp     = "timestamp"
+	ColumnTime          = "time"
+	ColumnJSON          = "json"
+	ColumnBinary        = "binary"
+)
+
+// Column 添加指定类型的字段，kind为Column*常量
+func (t *Table) Column(name, kind string) *Column {
+	c := &Column{name: name, kind: kind}
+	t.columns = append(t.columns, c)
+	return c
+}
+
+// ID 添加自增主键id
+func (t *Table) ID() *Column {
+	return t.BigIncrements("id")
+}
+
+// Increments 添加自增INT主键
+func (t *Table) Increments(name string) *Column {
+	c := t.Column(name, ColumnIncrements)
+	c.autoIncrement, c.primary, c.unsigned = true, true, true
+	return c
+}
+
+// BigIncrements 添加自增BIGINT主键
+func (t *Table) BigIncrements(name string) *Column {
+	c := t.Column(name, ColumnBigIncrements)
+	c.autoIncrement, c.primary, c.unsigned = true, true, true
+	return c
+}
+
+// String 添加VARCHAR字段
+func (t *Table) String(name string, size int) *Column {
+	c := t.Column(name, ColumnString)
+	c.size = size
+	return c
+}
+
+// Text 添加TEXT字段
+func (t *Table) Text(name string) *Column {
+	return t.Column(name, ColumnText)
+}
+
+// SmallInteger 添加SMALLINT字段
+func (t *Table) SmallInteger(name string) *Column {
+	return t.Column(name, ColumnSmallInteger)
+}
+
+// Integer 添加INT字段
+func (t *Table) Integer(name string) *Column {
+	return t.Column(name, ColumnInteger)
+}
+
+// BigInteger 添加BIGINT字段
+func (t *Table) BigInteger(name string) *Column {
+	return t.Column(name, ColumnBigInteger)
+}
+
+// Boolean 添加布尔字段
+func (t *Table) Boolean(name string) *Column {
+	return t.Column(name, ColumnBoolean)
+}
+
+// Float 添加单精度浮点字段
+func (t *Table) Float(name string) *Column {
+	return t.Column(name, ColumnFloat)
+}
+
+// Double 添加双精度浮点字段
+func (t *Table) Double(name string) *Column {
+	return t.Column(name, ColumnDouble)
+}
+
+// Decimal 添加DECIMAL字段
+func (t *Table) Decimal(name string, precision, scale int) *Column {
+	c := t.Column(name, ColumnDecimal)
+	c.precision, c.scale = precision, scale
+	return c
+}
+
+// Date 添加日期字段
+func (t *Table) Date(name string) *Column {
+	return t.Column(name, ColumnDate)
+}
+
+// DateTime 添加日期时间字段
+func (t *Table) DateTime(name string) *Column {
+	return t.Column(name, ColumnDateTime)
+}
+
+// Timestamp 添加TIMESTAMP字段
+func (t *Table) Timestamp(name string) *Column {
+	return t.Column(name, ColumnTimestamp)
+}
+
+// Time 添加时间字段
+func (t *Table) Time(name string) *Column {
+	return t.Column(name, ColumnTime)
+}
+
+// JSON 添加JSON字段，sqlite为TEXT
+func (t *Table) JSON(name string) *Column {
+	return t.Column(name, ColumnJSON)
+}
+
+// Binary 添加二进制字段
+func (t *Table) Binary(name string) *Column {
+	return t.Column(name, ColumnBinary)
+}
+
+// Timestamps 添加可为空的created_at及updated_at字段
+func (t *Table) Timestamps() {
+	t.DateTime("created_at").Nullable()
+	t.DateTime("updated_at").Nullable()
+}
+
+// SoftDeletes 添加可为空的软删除字段deleted_at
+func (t *Table) SoftDeletes() *Column {
+	return t.DateTime("deleted_at").Nullable()
+}
+
+// PrimaryKey 设置联合主键
+func (t *Table) PrimaryKey(columns ...string) {
+	t.primary = columns
+}
+
+// Index 添加索引，索引名为 idx_表名_字段名
+func (t *Table) Index(columns ...string) {
+	t.indexes = append(t.indexes, &tableIndex{name: t.indexName("idx", columns), columns: columns})
+}
+
+// UniqueIndex 添加唯一索引，索引名为 uniq_表名_字段名
+func (t *Table) UniqueIndex(columns ...string) {
+	t.indexes = append(t.indexes, &tableIndex{name: t.indexName("uniq", columns), columns: columns, unique: true})
+}
+
+// DropColumn 删除字段，sqlite需3.35及以上版本
+func (t *Table) DropColumn(names ...string) {
+	t.dropColumns = append(t.dropColumns, names...)
+}
+
+// RenameColumn 重命名字段
+func (t *Table) RenameColumn(from, to string) {
+	t.renameColumns = append(t.renameColumns, [2]string{from, to})
+}
+
+// DropIndex 删除索引
+func (t *Table) DropIndex(name string) {
+	t.dropIndexes = append(t.dropIndexes, name)
+}
+
+func (t *Table) indexName(prefix string, columns []string) string {
+	return prefix + "_" + t.name + "_" + strings.Join(columns, "_")
+}
+
+// Nullable 允许为NULL，字段默认为NOT NULL
+func (c *Column) Nullable() *Column {
+	c.nullable = true
+	return c
+}
+
+// Default 设置默认值，值按方言转义
+func (c *Column) Default(v interface{}) *Column {
+	c.defaultValue, c.defaultRaw, c.hasDefault = v, "", true
+	return c
+}
+
+// DefaultRaw 设置默认值表达式，如 CURRENT_TIMESTAMP
+func (c *Column) DefaultRaw(expr string) *Column {
+	c.defaultValue, c.defaultRaw, c.hasDefault = nil, expr, true
+	return c
+}
+
+// Unsigned 无符号整数，仅mysql生效
+func (c *Column) Unsigned() *Column {
+	c.unsigned = true
+	return c
+}
+
+// Primary 设置为主键
+func (c *Column) Primary() *Column {
+	c.primary = true
+	return c
+}
+
+// Unique 设置唯一约束
+func (c *Column) Unique() *Column {
+	c.unique = true
+	return c
+}
+
+// columnType 按方言返回字段类型
+func (t *Table) columnType(c *Column) string {
+	unsigned := If(c.unsigned && t.dialect == DialectMySQL, " UNSIGNED", "").(string)
+	switch c.kind {
+	case ColumnIncrements, ColumnBigIncrements:
+		big := c.kind == ColumnBigIncrements
+		switch t.dialect {
+		case DialectPostgres:
+			return If(big, "BIGSERIAL", "SERIAL").(string)
+		case DialectSQLite:
+			return "INTEGER"
+		}
+		return If(big, "BIGINT", "INT").(string) + unsigned
+	case ColumnString:
+		return fmt.Sprintf("VARCHAR(%d)", If(c.size > 0, c.size, 255))
+	case ColumnText:
+		return "TEXT"
+	case ColumnSmallInteger:
+		return "SMALLINT" + unsigned
+	case ColumnInteger:
+		return If(t.dialect == DialectMySQL, "INT", "INTEGER").(string) + unsigned
+	case ColumnBigInteger:
+		return "BIGINT" + unsigned
+	case ColumnBoolean:
+		return If(t.dialect == DialectMySQL, "TINYINT(1)", "BOOLEAN").(string)
+	case ColumnFloat:
+		return If(t.dialect == DialectMySQL, "FLOAT", "REAL").(string)
+	case ColumnDouble:
+		return map[string]string{DialectMySQL: "DOUBLE", DialectPostgres: "DOUBLE PRECISION", DialectSQLite: "REAL"}[t.dialect]
+	case ColumnDecimal:
+		return fmt.Sprintf("DECIMAL(%d, %d)", If(c.precision > 0, c.precision, 10), c.scale)
+	case ColumnDate:
+		return "DATE"
+	case ColumnDateTime:
+		return If(t.dialect == DialectPostgres, "TIMESTAMP", "DATETIME").(string)
+	case ColumnTimestamp:
+		return "TIMESTAMP"
+	case ColumnTime:
+		return "TIME"
+	case ColumnJSON:
+		return map[string]string{DialectMySQL: "JSON", DialectPostgres: "JSONB", DialectSQLite: "TEXT"}[t.dialect]
+	case ColumnBinary:
+		return If(t.dialect == DialectPostgres, "BYTEA", "BLOB").(string)
+	}
+	return strings.ToUpper(c.kind)
+}
+
+// columnSQL 返回字段定义
+func (t *Table) columnSQL(c *Column) string {
+	parts := []string{quoteIdent(t.dialect, c.name), t.columnType(c)}
+	if c.autoIncrement {
+		// 自增字段总是主键
+		switch t.dialect {
+		case DialectSQLite:
+			return strings.Join(append(parts, "PRIMARY KEY AUTOINCREMENT"), " ")
+		case DialectMySQL:
+			parts = append(parts, "NOT NULL AUTO_INCREMENT")
+		}
+		return strings.Join(append(parts, "PRIMARY KEY"), " ")
+	}
+	parts = append(parts, If(c.nullable, "NULL", "NOT NULL").(string))
+	if c.hasDefault {
+		parts = append(parts, "DEFAULT "+t.defaultSQL(c))
+	}
+	if c.primary && len(t.primary) == 0 {
+		parts = append(parts, "PRIMARY KEY")
+	}
+	if c.unique {
+		parts = append(parts, "UNIQUE")
+	}
+	return strings.Join(parts, " ")
+}
+
+// defaultSQL 返回默认值，按方言转义
+func (t *Table) defaultSQL(c *Column) string {
+	if c.defaultRaw != "" {
+		return c.defaultRaw
+	}
+	return QuoteValue(map[string]string{DialectMySQL: "mysql", DialectPostgres: "postgres", DialectSQLite: "sqlite3"}[t.dialect], c.defaultValue)
+}
+
+// createSQL 返回建表及建索引语句
+func (t *Table) createSQL(ifNotExists bool) []string {
+	var defs []string
+	for _, c := range t.columns {
+		defs = append(defs, t.columnSQL(c))
+	}
+	if len(t.primary) > 0 {
+		defs = append(defs, "PRIMARY KEY ("+t.quoteColumns(t.primary)+")")
+	}
+	stmt := fmt.Sprintf("CREATE TABLE %s%s (\n\t%s\n)", If(ifNotExists, "IF NOT EXISTS ", ""),
+		quoteIdent(t.dialect, t.name), strings.Join(defs, ",\n\t"))
+	if t.dialect == DialectMySQL {
+		stmt += " DEFAULT CHARSET=" + DefaultCharset
+	}
+	stmts := []string{stmt}
+	for _, idx := range t.indexes {
+		stmts = append(stmts, t.indexSQL(idx, ifNotExists))
+	}
+	return stmts
+}
+
+// alterSQL 返回修改表的语句，每个操作一条语句以兼容sqlite
+func (t *Table) alterSQL() ([]string, error) {
+	table := quoteIdent(t.dialect, t.name)
+	var stmts []string
+	for _, c := range t.columns {
+		if c.autoIncrement || c.primary {
+			return nil, fmt.Errorf("cannot add primary key column %s to existing table %s", c.name, t.name)
+		}
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, t.columnSQL(c)))
+	}
+	for _, r := range t.renameColumns {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", table,
+			quoteIdent(t.dialect, r[0]), quoteIdent(t.dialect, r[1])))
+	}
+	for _, name := range t.dropIndexes {
+		if t.dialect == DialectMySQL {
+			stmts = append(stmts, fmt.Sprintf("DROP INDEX %s ON %s", quoteIdent(t.dialect, name), table))
+		} else {
+			stmts = append(stmts, "DROP INDEX "+quoteIdent(t.dialect, name))
+		}
+	}
+	for _, name := range t.dropColumns {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, quoteIdent(t.dialect, name)))
+	}
+	for _, idx := range t.indexes {
+		stmts = append(stmts, t.indexSQL(idx, false))
+	}
+	return stmts, nil
+}
+
+// indexSQL 返回建索引语句，mysql不支持 IF NOT EXISTS
+func (t *Table) indexSQL(idx *tableIndex, ifNotExists bool) string {
+	return fmt.Sprintf("CREATE %sINDEX %s%s ON %s (%s)", If(idx.unique, "UNIQUE ", ""),
+		If(ifNotExists && t.dialect != DialectMySQL, "IF NOT EXISTS ", ""), quoteIdent(t.dialect, idx.name),
+		quoteIdent(t.dialect, t.name), t.quoteColumns(idx.columns))
+}
+
+func (t *Table) quoteColumns(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = quoteIdent(t.dialect, c)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,123 @@
+package builder
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSchemaDialects(t *testing.T) {
+	define := func(t *Table) {
+		t.ID()
+		t.String("name", 45).Nullable()
+		t.Boolean("active").Default(true)
+		t.Decimal("price", 10, 2).Default(0)
+		t.Timestamps()
+		t.UniqueIndex("name")
+	}
+	expected := map[string]string{
+		DialectMySQL: "CREATE TABLE `person` (\n\t`id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,\n\t`name` VARCHAR(45) NULL,\n\t" +
+			"`active` TINYINT(1) NOT NULL DEFAULT 1,\n\t`price` DECIMAL(10, 2) NOT NULL DEFAULT 0,\n\t" +
+			"`created_at` DATETIME NULL,\n\t`updated_at` DATETIME NULL\n) DEFAULT CHARSET=utf8mb4;" +
+			"CREATE UNIQUE INDEX `uniq_person_name` ON `person` (`name`)",
+		DialectPostgres: "CREATE TABLE \"person\" (\n\t\"id\" BIGSERIAL PRIMARY KEY,\n\t\"name\" VARCHAR(45) NULL,\n\t" +
+			"\"active\" BOOLEAN NOT NULL DEFAULT TRUE,\n\t\"price\" DECIMAL(10, 2) NOT NULL DEFAULT 0,\n\t" +
+			"\"created_at\" TIMESTAMP NULL,\n\t\"updated_at\" TIMESTAMP NULL\n);" +
+			"CREATE UNIQUE INDEX \"uniq_person_name\" ON \"person\" (\"name\")",
+		DialectSQLite: "CREATE TABLE \"person\" (\n\t\"id\" INTEGER PRIMARY KEY AUTOINCREMENT,\n\t\"name\" VARCHAR(45) NULL,\n\t" +
+			"\"active\" BOOLEAN NOT NULL DEFAULT 1,\n\t\"price\" DECIMAL(10, 2) NOT NULL DEFAULT 0,\n\t" +
+			"\"created_at\" DATETIME NULL,\n\t\"updated_at\" DATETIME NULL\n);" +
+			"CREATE UNIQUE INDEX \"uniq_person_name\" ON \"person\" (\"name\")",
+	}
+	for dialect, sql := range expected {
+		s := &SchemaBuilder{dialect: dialect}
+		if got := strings.Join(s.table("person", define).createSQL(false), ";"); got != sql {
+			t.Errorf("%s:\n%s", dialect, got)
+		}
+	}
+
+	s := &SchemaBuilder{dialect: DialectMySQL}
+	stmts, _ := s.table("person", func(t *Table) {
+		t.Integer("age").Unsigned().Default(0)
+		t.RenameColumn("name", "nickname")
+		t.DropIndex("uniq_person_name")
+	}).alterSQL()
+	if got := strings.Join(stmts, ";"); got != "ALTER TABLE `person` ADD COLUMN `age` INT UNSIGNED NOT NULL DEFAULT 0;"+
+		"ALTER TABLE `person` RENAME COLUMN `name` TO `nickname`;DROP INDEX `uniq_person_name` ON `person`" {
+		t.Errorf("unexpected alter sql: %s", got)
+	}
+	if _, err := s.table("person", func(t *Table) { t.ID() }).alterSQL(); err == nil {
+		t.Error("expected error adding primary key column")
+	}
+
+	// mysql使用反斜杠转义
+	stmts = s.table("person", func(t *Table) { t.String("dir", 45).Default(`C:\`) }).createSQL(false)
+	if !strings.Contains(stmts[0], "`dir` VARCHAR(45) NOT NULL DEFAULT 'C:\\\\'") {
+		t.Errorf("unexpected default: %s", stmts[0])
+	}
+}
+
+func TestCreateTableIfNotExistsMySQL(t *testing.T) {
+	db := newTestDB(t)
+	var execs []string
+	fake := func(ctx context.Context, stmt *Statement, next Handler) (*Result, error) {
+		if stmt.Kind == StatementSelect {
+			// 模拟数据表已存在
+			*(stmt.Dest.(*[]string)) = []string{"person"}
+			return &Result{Rows: 1}, nil
+		}
+		execs = append(execs, stmt.Query)
+		return next(ctx, stmt)
+	}
+	s := NewBuilder(db).Intercept(fake).Schema()
+	s.dialect = DialectMySQL
+	if err := s.CreateTableIfNotExists("person", func(t *Table) {
+		t.ID()
+		t.String("name", 45)
+		t.Index("name")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(execs) != 0 {
+		t.Errorf("expected no statements for existing table: %v", execs)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	db := newTestDB(t)
+	s := Schema(db)
+	err := s.CreateTable("person", func(t *Table) {
+		t.ID()
+		t.String("name", 45).Default("it's")
+		t.JSON("extra").Nullable()
+		t.Timestamps()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AlterTable("person", func(t *Table) {
+		t.Integer("age").Nullable()
+		t.RenameColumn("extra", "meta")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddIndex("person", "name", "age"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBuilder(db).Table("person").Fields("age").Insert(map[string]interface{}{"age": 3}); err != nil {
+		t.Fatal(err)
+	}
+	var name string
+	if err := db.Get(&name, "SELECT name FROM person WHERE meta IS NULL AND age = 3"); err != nil || name != "it's" {
+		t.Errorf("unexpected default %q %v", name, err)
+	}
+	if err := s.DropIndex("person", "idx_person_name_age"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DropTable("person"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DropTableIfExists("person"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,87 @@
+package builder
+
+// ScopeFunc 可复用的查询范围
+type ScopeFunc func(b *Builder) *Builder
+
+type namedScope struct {
+	name string
+	f    ScopeFunc
+}
+
+// GlobalScope 为模型添加全局查询范围，BuildQuery/BuildExec时自动应用
+func GlobalScope(name string, f ScopeFunc) ModelOption {
+	return func(m *Model) {
+		m.scopes = append(m.scopes, namedScope{name: name, f: f})
+	}
+}
+
+// RegisterScope 为数据表添加全局查询范围，表未注册时自动注册
+func RegisterScope(table, name string, f ScopeFunc) {
+	models.Lock()
+	defer models.Unlock()
+	key := DefaultMapper(table)
+	m := &Model{Table: table}
+	if old, ok := models.m[key]; ok {
+		*m = *old
+		m.scopes = append([]namedScope{}, old.scopes...)
+	}
+	GlobalScope(name, f)(m)
+	models.m[key] = m
+}
+
+func hasScope(scopes []namedScope, name string) bool {
+	for _, s := range scopes {
+		if s.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Scope 在链式操作中应用查询范围
+func (b *Builder) Scope(fs ...ScopeFunc) *Builder {
+	for _, f := range fs {
+		b = f(b)
+	}
+	return b
+}
+
+// WithoutScope 忽略指定名称的全局查询范围，不传参数则忽略全部
+func (b *Builder) WithoutScope(names ...string) *Builder {
+	if len(names) == 0 {
+		b.withoutAllScopes = true
+		return b
+	}
+	b.withoutScopes = append(b.withoutScopes, names...)
+	return b
+}
+
+// scoped 返回应用全局查询范围后的builder，不修改当前builder
+func (b *Builder) scoped() *Builder {
+	if b.scopesApplied || b.withoutAllScopes {
+		return b
+	}
+	m := b.model()
+	if m == nil || len(m.scopes) == 0 {
+		return b
+	}
+	cp := b.Copy()
+	cp.scopesApplied = true
+	// 原有条件及每个范围添加的条件分别作为一组，避免OR跨越范围条件
+	var groups [][]interface{}
+	if len(cp.query.Where) > 0 {
+		groups = append(groups, []interface{}{"AND", cp.query.Where, []interface{}{}})
+	}
+	for _, s := range m.scopes {
+		if indexOf(s.name, b.withoutScopes) != -1 {
+			continue
+		}
+		cp.query.Where = nil
+		cp = s.f(cp)
+		if len(cp.query.Where) > 0 {
+			groups = append(groups, []interface{}{"AND", cp.query.Where, []interface{}{}})
+		}
+	}
+	cp.query.Where = groups
+	return cp
+}
@@ -0,0 +1,49 @@
+package builder
+
+import "testing"
+
+func TestScope(t *testing.T) {
+	active := func(b *Builder) *Builder { return b.Where("active = ?", 1) }
+	query, args, _ := NewBuilder(nil).Table("account").Scope(active).Where("id = ?", 2).BuildQuery()
+	if query != "SELECT * FROM account WHERE active = ? AND id = ?" || len(args) != 2 {
+		t.Errorf("unexpected query: %s %v", query, args)
+	}
+
+	RegisterScope("account", "visible", func(b *Builder) *Builder { return b.Where("visible = 1") })
+	RegisterScope("account", "tenant", func(b *Builder) *Builder { return b.Where("tenant_id = ?", 9) })
+	defer UnregisterModel("account")
+
+	b := NewBuilder(nil).Table("account").Where("id = ?", 2)
+	query, args, _ = b.BuildQuery()
+	if query != "SELECT * FROM account WHERE (id = ?) AND (visible = 1) AND (tenant_id = ?)" || len(args) != 2 {
+		t.Errorf("unexpected query: %s %v", query, args)
+	}
+	// 全局范围不应修改原builder
+	if len(b.query.Where) != 1 {
+		t.Errorf("global scope mutated builder: %v", b.query.Where)
+	}
+
+	query, _, _ = NewBuilder(nil).Table("account").WithoutScope("tenant").BuildExec("DELETE", nil)
+	if query != "DELETE  FROM account WHERE (visible = 1)" {
+		t.Errorf("unexpected query: %s", query)
+	}
+	// 用户条件中的OR不能绕过全局范围
+	query, args, _ = NewBuilder(nil).Table("account").Where("a = ? OR b IN (?)", 1, []int{2, 3}).BuildQuery()
+	if query != "SELECT * FROM account WHERE (a = ? OR b IN (?, ?)) AND (visible = 1) AND (tenant_id = ?)" || len(args) != 4 {
+		t.Errorf("unexpected query: %s %v", query, args)
+	}
+	query, _, _ = NewBuilder(nil).Table("account").WithoutScope().BuildQuery()
+	if query != "SELECT * FROM account" {
+		t.Errorf("unexpected query: %s", query)
+	}
+}
+
+func TestRegisterModelKeepsScopes(t *testing.T) {
+	RegisterScope("account", "visible", func(b *Builder) *Builder { return b.Where("visible = 1") })
+	RegisterModel("account", SoftDelete("deleted_at"))
+	defer UnregisterModel("account")
+	query, _, _ := NewBuilder(nil).Table("account").BuildQuery()
+	if query != "SELECT * FROM account WHERE ((visible = 1)) AND account.deleted_at IS NULL" {
+		t.Errorf("unexpected query: %s", query)
+	}
+}
@@ -0,0 +1,197 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"reflect"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrShardKeyRequired 分片表执行写操作或单条查询时未设置分片键
+var ErrShardKeyRequired = errors.New("shard key is required")
+
+// Shard 分片，数据库连接及物理表名
+type Shard struct {
+	DB    *sqlx.DB
+	Table string
+}
+
+// ShardStrategy 分片策略
+type ShardStrategy interface {
+	// Shard 返回分片键所在的分片
+	Shard(table string, key interface{}) (*Shard, error)
+	// Shards 返回所有分片，用于未设置分片键时的分散查询
+	Shards(table string) []*Shard
+}
+
+// ModSharding 按分片键取模分表，第i张表位于DBs[i%len(DBs)]
+type ModSharding struct {
+	DBs []*sqlx.DB
+	// Tables 分表数量
+	Tables int
+	// Format 物理表名格式，参数为逻辑表名及分表序号，默认为 %s_%02d
+	Format string
+}
+
+// Shard 整数分片键直接取模，其他类型取crc32后取模
+func (s *ModSharding) Shard(table string, key interface{}) (*Shard, error) {
+	if s.Tables <= 0 || len(s.DBs) == 0 {
+		return nil, errors.New("mod sharding requires tables and dbs")
+	}
+	var n uint64
+	rv := reflect.ValueOf(key)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if rv.Int() < 0 {
+			return nil, fmt.Errorf("invalid shard key %d", rv.Int())
+		}
+		n = uint64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = rv.Uint()
+	default:
+		n = uint64(crc32.ChecksumIEEE([]byte(fmt.Sprint(key))))
+	}
+	return s.shard(table, int(n%uint64(s.Tables))), nil
+}
+
+// Shards 返回所有分表
+func (s *ModSharding) Shards(table string) []*Shard {
+	var result []*Shard
+	if len(s.DBs) == 0 {
+		return result
+	}
+	for i := 0; i < s.Tables; i++ {
+		result = append(result, s.shard(table, i))
+	}
+	return result
+}
+
+func (s *ModSharding) shard(table string, i int) *Shard {
+	format := s.Format
+	if format == "" {
+		format = "%s_%02d"
+	}
+	return &Shard{DB: s.DBs[i%len(s.DBs)], Table: fmt.Sprintf(format, table, i)}
+}
+
+// Sharding 设置模型的分片策略
+func Sharding(s ShardStrategy) ModelOption {
+	return func(m *Model) {
+		m.Sharding = s
+	}
+}
+
+// ShardBy 设置分片键，由模型的分片策略选择数据库及物理表
+func (b *Builder) ShardBy(key interface{}) *Builder {
+	b.shardKey = key
+	b.hasShardKey = true
+	return b
+}
+
+// currentShard 返回当前语句的分片，未启用分片时返回nil
+func (b *Builder) currentShard() (*Shard, error) {
+	if b.shard != nil {
+		return b.shard, nil
+	}
+	m := b.model()
+	if m == nil || m.Sharding == nil {
+		return nil, nil
+	}
+	if !b.hasShardKey {
+		return nil, ErrShardKeyRequired
+	}
+	return m.Sharding.Shard(DefaultMapper(b.query.Table), b.shardKey)
+}
+
+// columnQualifier 返回自动附加条件(软删除、租户)中限定字段的表名
+func (b *Builder) columnQualifier() string {
+	if b.qualifier != "" {
+		return b.qualifier
+	}
+	return DefaultMapper(b.query.Table)
+}
+
+// tableName 返回语句中的表名，分片时为物理表，alias为true时为 物理表 AS table，保证带表名的条件可用
+func (b *Builder) tableName(table string, alias bool) (string, error) {
+	shard, err := b.currentShard()
+	if err != nil || shard == nil {
+		return table, err
+	}
+	if alias {
+		return shard.Table + " AS " + table, nil
+	}
+	return shard.Table, nil
+}
+
+// scatter 返回需要分散查询的各分片builder，已确定分片时返回nil
+func (b *Builder) scatter() []*Builder {
+	if b.shard != nil || b.hasShardKey {
+		return nil
+	}
+	m := b.model()
+	if m == nil || m.Sharding == nil {
+		return nil
+	}
+	var result []*Builder
+	for _, s := range m.Sharding.Shards(DefaultMapper(b.query.Table)) {
+		cp := b.Copy()
+		cp.shard = s
+		result = append(result, cp)
+	}
+	return result
+}
+
+// scatterAll 并发查询所有分片，结果按分片顺序合并到dest；排序及分页仅在各分片内生效
+func scatterAll(shards []*Builder, dest interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return errors.New("scatter query dest must be a pointer to slice")
+	}
+	results := make([]reflect.Value, len(shards))
+	errs := make([]error, len(shards))
+	var wg sync.WaitGroup
+	for i, s := range shards {
+		wg.Add(1)
+		go func(i int, s *Builder) {
+			defer wg.Done()
+			results[i] = reflect.New(rv.Elem().Type())
+			errs[i] = s.All(results[i].Interface())
+		}(i, s)
+	}
+	wg.Wait()
+	merged := reflect.MakeSlice(rv.Elem().Type(), 0, 0)
+	for i := range shards {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		merged = reflect.AppendSlice(merged, results[i].Elem())
+	}
+	rv.Elem().Set(merged)
+	return nil
+}
+
+// scatterCount 并发统计所有分片并求和
+func scatterCount(shards []*Builder) (int64, error) {
+	counts := make([]int64, len(shards))
+	errs := make([]error, len(shards))
+	var wg sync.WaitGroup
+	for i, s := range shards {
+		wg.Add(1)
+		go func(i int, s *Builder) {
+			defer wg.Done()
+			counts[i], errs[i] = s.Count()
+		}(i, s)
+	}
+	wg.Wait()
+	var total int64
+	for i := range shards {
+		if errs[i] != nil {
+			return 0, errs[i]
+		}
+		total += counts[i]
+	}
+	return total, nil
+}
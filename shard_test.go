@@ -0,0 +1,61 @@
+package builder
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestSharding(t *testing.T) {
+	var dbs []*sqlx.DB
+	for i := 0; i < 2; i++ {
+		dbs = append(dbs, newTestDB(t))
+	}
+	for i := 0; i < 4; i++ {
+		dbs[i%2].MustExec(fmt.Sprintf("CREATE TABLE orders_%02d (id INTEGER PRIMARY KEY, user_id INTEGER, deleted_at DATETIME NULL)", i))
+	}
+	RegisterModel("orders", Sharding(&ModSharding{DBs: dbs, Tables: 4}), SoftDelete("deleted_at"))
+	defer UnregisterModel("orders")
+
+	for _, uid := range []int64{1, 2, 5, 6, 7} {
+		if _, err := NewBuilder(nil).Table("orders").ShardBy(uid).Fields("user_id").Insert(map[string]interface{}{"user_id": uid}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var n int
+	dbs[1].Get(&n, "SELECT COUNT(*) FROM orders_01")
+	if n != 2 {
+		t.Errorf("expected 2 rows in orders_01, got %d", n)
+	}
+
+	var uids []int64
+	if err := NewBuilder(nil).Table("orders").ShardBy(5).Fields("user_id").Where("user_id = ?", 5).All(&uids); err != nil || len(uids) != 1 {
+		t.Errorf("unexpected shard query: %v %v", uids, err)
+	}
+	if _, err := NewBuilder(nil).Table("orders").ShardBy(5).Where("user_id = ?", 5).Delete(); err != nil {
+		t.Fatal(err)
+	}
+
+	uids = nil
+	if err := NewBuilder(nil).Table("orders").Fields("user_id").OrderBy("user_id").All(&uids); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(uids) != "[1 2 6 7]" {
+		t.Errorf("unexpected scatter result: %v", uids)
+	}
+	if total, err := NewBuilder(nil).Table("orders").WithTrashed().Count(); err != nil || total != 5 {
+		t.Errorf("expected scatter count 5, got %d %v", total, err)
+	}
+	query, _, _ := NewBuilder(nil).Table("orders").ShardBy(6).WithTrashed().Where("user_id = ?", 6).BuildExec("DELETE", nil)
+	if query != "DELETE  FROM orders_02 WHERE user_id = ?" {
+		t.Errorf("unexpected delete: %s", query)
+	}
+	if _, err := NewBuilder(nil).Table("orders").ShardBy(6).OnlyTrashed().ForceDelete(); err != nil {
+		t.Fatal(err)
+	}
+	var id int64
+	if err := NewBuilder(nil).Table("orders").Fields("id").One(&id); err != ErrShardKeyRequired {
+		t.Errorf("expected ErrShardKeyRequired, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package builder
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// ErrNoSoftDelete 数据表未设置软删除字段
+var ErrNoSoftDelete = errors.New("soft delete column is not defined")
+
+// 软删除数据的查询范围
+const (
+	trashedDefault = iota // 默认排除已删除数据
+	trashedWith           // 包含已删除数据
+	trashedOnly           // 仅查询已删除数据
+)
+
+// WithTrashed 查询结果包含已软删除的数据
+func (b *Builder) WithTrashed() *Builder {
+	b.trashed = trashedWith
+	return b
+}
+
+// OnlyTrashed 仅查询已软删除的数据
+func (b *Builder) OnlyTrashed() *Builder {
+	b.trashed = trashedOnly
+	return b
+}
+
+// Restore 恢复已软删除的数据
+func (b *Builder) Restore() (sql.Result, error) {
+	column := b.softDeleteColumn()
+	if column == "" {
+		return nil, ErrNoSoftDelete
+	}
+	cp := b.Copy()
+	cp.query.Fields = nil
+	if cp.trashed == trashedDefault {
+		cp.trashed = trashedOnly
+	}
+	data := map[string]interface{}{column: nil}
+	cp.touchTimestamps("UPDATE", nil, data)
+	return cp._execData("UPDATE", data)
+}
+
+// ForceDelete 物理删除数据，忽略软删除设置
+func (b *Builder) ForceDelete() (sql.Result, error) {
+	cp := b.Copy()
+	cp.query.Fields = nil
+	if cp.trashed == trashedDefault {
+		cp.trashed = trashedWith
+	}
+	return cp._execData("DELETE", nil)
+}
+
+// softDeleteColumn 返回当前表的软删除字段
+func (b *Builder) softDeleteColumn() string {
+	if m := b.model(); m != nil {
+		return m.SoftDelete
+	}
+	return ""
+}
+
+// softDeleteWhere 返回软删除的过滤条件
+func (b *Builder) softDeleteWhere() string {
+	column := b.softDeleteColumn()
+	if column == "" {
+		return ""
+	}
+	column = b.columnQualifier() + "." + column
+	switch b.trashed {
+	case trashedWith:
+		return ""
+	case trashedOnly:
+		return column + " IS NOT NULL"
+	default:
+		return column + " IS NULL"
+	}
+}
@@ -0,0 +1,93 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type testPost struct {
+	ID        int64   `db:"id" json:"id"`
+	Title     string  `db:"title" json:"title"`
+	DeletedAt *string `db:"deleted_at" json:"-"`
+}
+
+func newTestDB(t *testing.T, schemas ...string) *sqlx.DB {
+	db, err := Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	for _, s := range schemas {
+		db.MustExec(s)
+	}
+	return db
+}
+
+func TestSoftDelete(t *testing.T) {
+	RegisterModel("post", SoftDelete("deleted_at"))
+	defer UnregisterModel("post")
+	db := newTestDB(t, "CREATE TABLE post (id INTEGER PRIMARY KEY, title TEXT, deleted_at DATETIME NULL)")
+	for _, title := range []string{"a", "b", "c"} {
+		if _, err := NewBuilder(db).Table("post").Fields("title").Insert(&testPost{Title: title}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	query, _, _ := NewBuilder(db).Table("post").Where("id = ?", 1).BuildQuery()
+	if query != "SELECT * FROM post WHERE (id = ?) AND post.deleted_at IS NULL" {
+		t.Errorf("unexpected query: %s", query)
+	}
+
+	if _, err := NewBuilder(db).Table("post").Where("title = ?", "a").Delete(); err != nil {
+		t.Fatal(err)
+	}
+	count := func(b *Builder) int64 {
+		n, err := b.Count()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if n := count(NewBuilder(db).Table("post")); n != 2 {
+		t.Errorf("expected 2 rows, got %d", n)
+	}
+	if n := count(NewBuilder(db).Table("post").WithTrashed()); n != 3 {
+		t.Errorf("expected 3 rows with trashed, got %d", n)
+	}
+	var trashed []testPost
+	if err := NewBuilder(db).Table("post").OnlyTrashed().All(&trashed); err != nil {
+		t.Fatal(err)
+	}
+	if len(trashed) != 1 || trashed[0].Title != "a" || trashed[0].DeletedAt == nil {
+		t.Errorf("unexpected trashed rows: %+v", trashed)
+	}
+
+	if _, err := NewBuilder(db).Table("post").Where("title = ?", "a").Restore(); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(NewBuilder(db).Table("post")); n != 3 {
+		t.Errorf("expected 3 rows after restore, got %d", n)
+	}
+
+	// Fields不影响软删除字段
+	if _, err := NewBuilder(db).Table("post").Fields("title").Where("title = ?", "b").Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(NewBuilder(db).Table("post")); n != 2 {
+		t.Errorf("expected 2 rows after delete with fields, got %d", n)
+	}
+	if _, err := NewBuilder(db).Table("post").Where("title = ?", "b").ForceDelete(); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(NewBuilder(db).Table("post").WithTrashed()); n != 2 {
+		t.Errorf("expected 2 rows after force delete, got %d", n)
+	}
+}
+
+func TestRestoreWithoutSoftDelete(t *testing.T) {
+	if _, err := NewBuilder(nil).Table("person").Restore(); err != ErrNoSoftDelete {
+		t.Errorf("expected ErrNoSoftDelete, got %v", err)
+	}
+}
@@ -0,0 +1,251 @@
+package builder
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// SensitiveColumns 全局敏感字段，ToSQL及日志中的值会被替换为RedactedValue
+	SensitiveColumns = []string{"password", "passwd", "secret", "token", "access_token", "refresh_token", "api_key"}
+	// RedactedValue 敏感字段替换后的显示值
+	RedactedValue = "[REDACTED]"
+)
+
+// 占位符前的字段名，如 `password` = ?、t.token IN (?, ?
+var placeholderColumn = regexp.MustCompile("(?i)([\\w.`\"]+)\\s*(?:=|!=|<>|<=|>=|<|>|\\bLIKE|\\bIN\\s*\\(\\s*(?:\\?\\s*,\\s*)*)\\s*$")
+
+// Sensitive 设置模型的敏感字段
+func Sensitive(columns ...string) ModelOption {
+	return func(m *Model) {
+		m.Sensitive = append(m.Sensitive, columns...)
+	}
+}
+
+// ToSQL 返回插值后的完整查询语句，仅用于调试显示，敏感字段的值会被隐藏
+func (b *Builder) ToSQL() (string, error) {
+	query, args, err := b.BuildQuery()
+	if err != nil {
+		return "", err
+	}
+	return Interpolate(b.driverName(), query, b.redactArgs(query, args))
+}
+
+// String 实现fmt.Stringer，返回ToSQL的结果
+func (b *Builder) String() string {
+	s, err := b.ToSQL()
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return s
+}
+
+// driverName 返回数据库引擎名称
+func (b *Builder) driverName() string {
+	if b.tx != nil {
+		return b.tx.DriverName()
+	}
+	if b.db != nil {
+		return b.db.DriverName()
+	}
+	return ""
+}
+
+// isSensitive 判断字段是否为敏感字段
+func (b *Builder) isSensitive(column string) bool {
+	column = strings.Trim(column[strings.LastIndex(column, ".")+1:], "`\"")
+	column = strings.ToLower(column)
+	if indexOf(column, SensitiveColumns) != -1 {
+		return true
+	}
+	if m := b.model(); m != nil {
+		return indexOf(column, m.Sensitive) != -1
+	}
+	return false
+}
+
+// redactData 返回隐藏敏感字段后的数据，没有敏感字段时返回false
+func (b *Builder) redactData(data map[string]interface{}) (map[string]interface{}, bool) {
+	var result map[string]interface{}
+	for k := range data {
+		if b.isSensitive(k) {
+			if result == nil {
+				result = make(map[string]interface{}, len(data))
+				for k, v := range data {
+					result[k] = v
+				}
+			}
+			result[k] = RedactedValue
+		}
+	}
+	return result, result != nil
+}
+
+// redactArgs 返回隐藏敏感字段后的参数，按占位符前的字段名判断
+func (b *Builder) redactArgs(query string, args []interface{}) []interface{} {
+	if len(args) == 0 {
+		return args
+	}
+	result := make([]interface{}, len(args))
+	copy(result, args)
+	i := 0
+	forEachPlaceholder(query, func(pos int) {
+		if i < len(result) {
+			if m := placeholderColumn.FindStringSubmatch(query[:pos]); m != nil && b.isSensitive(m[1]) {
+				result[i] = RedactedValue
+			}
+		}
+		i++
+	})
+	return result
+}
+
+// forEachPlaceholder 遍历字符串字面量及注释以外的?占位符
+func forEachPlaceholder(query string, fn func(pos int)) {
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end == -1 {
+				return
+			}
+			i += end + 3
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end == -1 {
+				return
+			}
+			i += end
+		case c == '?':
+			fn(i)
+		}
+	}
+}
+
+// Interpolate 将参数按数据库方言转义后替换占位符，仅用于调试显示，不要用于执行
+func Interpolate(driverName, query string, args []interface{}) (string, error) {
+	var buf strings.Builder
+	last, i := 0, 0
+	var err error
+	forEachPlaceholder(query, func(pos int) {
+		if err != nil {
+			return
+		}
+		if i >= len(args) {
+			err = fmt.Errorf("not enough args to interpolate: %d", len(args))
+			return
+		}
+		buf.WriteString(query[last:pos])
+		buf.WriteString(QuoteValue(driverName, args[i]))
+		last = pos + 1
+		i++
+	})
+	if err != nil {
+		return "", err
+	}
+	if i != len(args) {
+		return "", fmt.Errorf("too many args to interpolate: %d", len(args))
+	}
+	buf.WriteString(query[last:])
+	return buf.String(), nil
+}
+
+// QuoteValue 按数据库方言返回值的sql字面量
+func QuoteValue(driverName string, v interface{}) string {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if val, err := valuer.Value(); err == nil {
+			v = val
+		}
+	}
+	switch val := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if driverName == "postgres" || driverName == "pgx" {
+			return strings.ToUpper(strconv.FormatBool(val))
+		}
+		return If(val, "1", "0").(string)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprintf("%v", val)
+	case time.Time:
+		return quoteString(driverName, val.Format(TimeFormat))
+	case []byte:
+		if driverName == "postgres" || driverName == "pgx" {
+			return `'\x` + hex.EncodeToString(val) + `'`
+		}
+		return "X'" + hex.EncodeToString(val) + "'"
+	case string:
+		return quoteString(driverName, val)
+	default:
+		return quoteString(driverName, fmt.Sprintf("%v", val))
+	}
+}
+
+// quoteString 转义字符串，mysql使用反斜杠转义，其他使用标准的单引号重复
+func quoteString(driverName, s string) string {
+	if driverName == "mysql" || driverName == "" {
+		r := strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\x00", `\0`, "\n", `\n`, "\r", `\r`, "\x1a", `\Z`)
+		return "'" + r.Replace(s) + "'"
+	}
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// SanitizeSQL 将sql中的字符串及数字字面量替换为?，用于tracing、metrics等不应包含数据的场景
+func SanitizeSQL(query string) string {
+	var buf strings.Builder
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'':
+			// 字符串字面量
+			for i++; i < len(query); i++ {
+				if query[i] == '\\' {
+					i++
+				} else if query[i] == '\'' {
+					if i+1 < len(query) && query[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			buf.WriteByte('?')
+		case c == '`' || c == '"':
+			// 标识符原样保留
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				buf.WriteString(query[i:])
+				return buf.String()
+			}
+			buf.WriteString(query[i : i+end+2])
+			i += end + 1
+		case c >= '0' && c <= '9' && (i == 0 || !isIdentByte(query[i-1])):
+			for i+1 < len(query) && (isIdentByte(query[i+1]) || query[i+1] == '.') {
+				i++
+			}
+			buf.WriteByte('?')
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,72 @@
+package builder
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInterpolate(t *testing.T) {
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	query := "SELECT * FROM t WHERE a = ? AND b = '?' AND c IN (?, ?) AND d = ? AND e = ?"
+	got, err := Interpolate("mysql", query, []interface{}{"it's", 1, nil, at, []byte{0xab}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `SELECT * FROM t WHERE a = 'it\'s' AND b = '?' AND c IN (1, NULL) AND d = '2020-01-02 03:04:05' AND e = X'ab'`
+	if got != want {
+		t.Errorf("unexpected mysql sql: %s", got)
+	}
+	if got, _ := Interpolate("sqlite3", "SELECT ?, ?", []interface{}{"it's", true}); got != "SELECT 'it''s', 1" {
+		t.Errorf("unexpected sqlite sql: %s", got)
+	}
+	if got, _ := Interpolate("postgres", "SELECT ?", []interface{}{true}); got != "SELECT TRUE" {
+		t.Errorf("unexpected postgres sql: %s", got)
+	}
+	if _, err := Interpolate("mysql", "SELECT ?", nil); err == nil {
+		t.Error("expected not enough args error")
+	}
+}
+
+func TestToSQLRedaction(t *testing.T) {
+	RegisterModel("member", Sensitive("pin"))
+	defer UnregisterModel("member")
+	b := NewBuilder(nil).Table("member").Where("name = ? AND password = ?", "tom", "secret").Where("pin IN (?)", []int{1, 2})
+	if got := b.String(); got != "SELECT * FROM member WHERE name = 'tom' AND password = '[REDACTED]' AND pin IN ('[REDACTED]', '[REDACTED]')" {
+		t.Errorf("unexpected sql: %s", got)
+	}
+
+	db := newTestDB(t, "CREATE TABLE member (id INTEGER PRIMARY KEY, password TEXT, pin INTEGER)")
+	var logged []LogEntry
+	logger := LoggerFunc(func(ctx context.Context, level LogLevel, e LogEntry) { logged = append(logged, e) })
+	if _, err := NewBuilder(db).Debug(true).Logger(logger).Table("member").Insert(map[string]interface{}{"id": 1, "password": "p@ss", "pin": 1234}); err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != 1 || strings.Contains(logged[0].Query, "p@ss") || strings.Contains(logged[0].Query, "1234") {
+		t.Errorf("secret leaked to logs: %+v", logged)
+	}
+	var pin int64
+	if err := NewBuilder(db).Table("member").Where("id = ?", 1).Value("pin", &pin); err != nil || pin != 1234 {
+		t.Errorf("redaction must not affect executed sql: %d %v", pin, err)
+	}
+
+	// 注释中的?不是占位符
+	b = NewBuilder(nil).Table("member").Comment("why?").Where("password = ?", "hunter2")
+	if got, err := b.ToSQL(); err != nil || got != "SELECT /* why? */ * FROM member WHERE password = '[REDACTED]'" {
+		t.Errorf("unexpected sql: %s %v", got, err)
+	}
+	if got, err := Interpolate("mysql", "SELECT 1 -- a?\nFROM t WHERE a = ?", []interface{}{1}); err != nil || got != "SELECT 1 -- a?\nFROM t WHERE a = 1" {
+		t.Errorf("unexpected sql: %s %v", got, err)
+	}
+	if got := NewBuilder(nil).Table("member").Comment("a */ b").String(); got != "SELECT /* a * / b */ * FROM member" {
+		t.Errorf("unexpected sql: %s", got)
+	}
+}
+
+func TestSanitizeSQL(t *testing.T) {
+	query := "INSERT  INTO `t1` (`name`, `age`) VALUES ('it''s \\' x', 18.5) /* c2 */"
+	if got := SanitizeSQL(query); got != "INSERT  INTO `t1` (`name`, `age`) VALUES (?, ?) /* c2 */" {
+		t.Errorf("unexpected sanitized sql: %s", got)
+	}
+}
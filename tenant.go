@@ -0,0 +1,131 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrTenantRequired 多租户表未指定租户
+var ErrTenantRequired = errors.New("tenant is required for tenant-scoped table")
+
+type tenantKey struct{}
+
+// ContextWithTenant 返回携带租户ID的context
+func ContextWithTenant(ctx context.Context, tenant interface{}) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext 从context读取租户ID
+func TenantFromContext(ctx context.Context) (interface{}, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	tenant := ctx.Value(tenantKey{})
+	return tenant, tenant != nil
+}
+
+// Tenant 设置租户字段，注册后该表的查询、更新、删除自动附加租户条件，写入时自动填充租户ID
+func Tenant(column string) ModelOption {
+	return func(m *Model) {
+		m.Tenant = column
+	}
+}
+
+// Tenant 设置当前builder的租户ID，优先于context中的租户
+func (b *Builder) Tenant(tenant interface{}) *Builder {
+	b.tenant = tenant
+	return b
+}
+
+// CrossTenant 跨租户操作，不附加租户条件，仅用于后台任务等明确需要的场景
+func (b *Builder) CrossTenant() *Builder {
+	b.crossTenant = true
+	return b
+}
+
+// tenantColumn 返回当前表的租户字段，未启用或跨租户时返回空
+func (b *Builder) tenantColumn() string {
+	if b.crossTenant {
+		return ""
+	}
+	if m := b.model(); m != nil {
+		return m.Tenant
+	}
+	return ""
+}
+
+// tenantID 返回当前租户ID
+func (b *Builder) tenantID() (interface{}, error) {
+	if b.tenant != nil {
+		return b.tenant, nil
+	}
+	if tenant, ok := TenantFromContext(b.ctx); ok {
+		return tenant, nil
+	}
+	return nil, ErrTenantRequired
+}
+
+// tenantWhere 返回租户过滤条件
+func (b *Builder) tenantWhere() ([]interface{}, error) {
+	column := b.tenantColumn()
+	if column == "" {
+		return nil, nil
+	}
+	tenant, err := b.tenantID()
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{"AND", b.columnQualifier() + "." + column + " = ?", []interface{}{tenant}}, nil
+}
+
+// ErrTenantJoin 关联的多租户表未指定ON条件，无法附加租户条件
+var ErrTenantJoin = errors.New("join of tenant-scoped table requires an ON condition")
+
+// joinTenantWhere 返回关联表的租户过滤条件，args为Join的参数(表名[, 别名], ON条件)
+func (b *Builder) joinTenantWhere(args []interface{}) ([]interface{}, error) {
+	if b.crossTenant {
+		return nil, nil
+	}
+	table := strings.Fields(args[0].(string))
+	if len(table) == 0 {
+		return nil, nil
+	}
+	m := GetModel(table[0])
+	if m == nil || m.Tenant == "" {
+		return nil, nil
+	}
+	if len(args) < 2 {
+		return nil, ErrTenantJoin
+	}
+	tenant, err := b.tenantID()
+	if err != nil {
+		return nil, err
+	}
+	qualifier := table[len(table)-1]
+	if len(args) == 3 {
+		qualifier = args[1].(string)
+	}
+	return []interface{}{"AND", qualifier + "." + m.Tenant + " = ?", []interface{}{tenant}}, nil
+}
+
+// withTenant 返回填充了租户ID的数据，force为false时仅覆盖已存在的租户字段
+func (b *Builder) withTenant(data map[string]interface{}, force bool) (map[string]interface{}, error) {
+	column := b.tenantColumn()
+	if column == "" {
+		return data, nil
+	}
+	if _, ok := data[column]; !ok && !force {
+		return data, nil
+	}
+	tenant, err := b.tenantID()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{}, len(data)+1)
+	for k, v := range data {
+		result[k] = v
+	}
+	result[column] = tenant
+	return result, nil
+}
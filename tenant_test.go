@@ -0,0 +1,98 @@
+package builder
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type testOrder struct {
+	ID       int64 `db:"id" json:"id"`
+	TenantID int64 `db:"tenant_id" json:"tenant_id" sqlxb:"tenant"`
+	Amount   int64 `db:"amount" json:"amount"`
+}
+
+func TestTenant(t *testing.T) {
+	RegisterModel("orders", FromStruct(testOrder{}))
+	defer UnregisterModel("orders")
+	db := newTestDB(t, "CREATE TABLE orders (id INTEGER PRIMARY KEY, tenant_id INTEGER, amount INTEGER)")
+
+	if _, err := NewBuilder(db).Table("orders").Insert(&testOrder{ID: 1, Amount: 10}); err != ErrTenantRequired {
+		t.Fatalf("expected ErrTenantRequired, got %v", err)
+	}
+	ctx := ContextWithTenant(context.Background(), int64(1))
+	if _, err := NewBuilder(db).WithContext(ctx).Table("orders").Fields("id", "amount").Insert(&testOrder{ID: 1, Amount: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBuilder(db).Tenant(int64(2)).Table("orders").Insert(&testOrder{ID: 2, TenantID: 1, Amount: 20}); err != nil {
+		t.Fatal(err)
+	}
+
+	var orders []testOrder
+	if err := NewBuilder(db).WithContext(ctx).Table("orders").All(&orders); err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 || orders[0].ID != 1 || orders[0].TenantID != 1 {
+		t.Errorf("unexpected orders: %+v", orders)
+	}
+	if _, err := NewBuilder(db).WithContext(ctx).Table("orders").Where("id = ?", 2).Update(map[string]interface{}{"amount": 0}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBuilder(db).Table("orders").Count(); err != ErrTenantRequired {
+		t.Errorf("expected ErrTenantRequired, got %v", err)
+	}
+	n, err := NewBuilder(db).Table("orders").CrossTenant().Where("amount > 0").Count()
+	if err != nil || n != 2 {
+		t.Errorf("expected 2 orders across tenants, got %d %v", n, err)
+	}
+}
+
+func TestTenantUpsert(t *testing.T) {
+	RegisterModel("orders", Tenant("tenant_id"))
+	defer UnregisterModel("orders")
+	query, args, err := NewBuilder(nil).Tenant("1' OR '1'='1").Table("orders").BuildExec("INSERT_ON_DUPLICATE_UPDATE", map[string]interface{}{"amount": 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := query[strings.Index(query, "UPDATE"):]
+	if strings.Contains(update, "UPDATE `tenant_id`") || !strings.Contains(update, "`amount` = IF(`tenant_id` = ?, VALUES(`amount`), `amount`)") {
+		t.Errorf("unexpected upsert: %s", query)
+	}
+	if strings.Contains(query, "1' OR") || len(args) != 2 || args[0] != "1' OR '1'='1" {
+		t.Errorf("tenant should be bound as arg: %s %v", query, args)
+	}
+}
+
+func TestTenantJoin(t *testing.T) {
+	RegisterModel("orders", FromStruct(testOrder{}))
+	defer UnregisterModel("orders")
+	db := newTestDB(t,
+		"CREATE TABLE person (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, person_id INTEGER, tenant_id INTEGER, amount INTEGER)",
+		"INSERT INTO person VALUES (1, 'tom')",
+		"INSERT INTO orders VALUES (1, 1, 1, 10), (2, 1, 2, 20)",
+	)
+	query, args, err := NewBuilder(db).Tenant(int64(1)).Table("person").Join("orders", "orders.person_id = person.id").Where("person.id = ?", 1).BuildQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "SELECT person.*, orders.* FROM person INNER JOIN orders ON (orders.person_id = person.id) AND orders.tenant_id = ? WHERE person.id = ?" || len(args) != 2 || args[0] != int64(1) {
+		t.Errorf("unexpected query: %s %v", query, args)
+	}
+	var amounts []int64
+	if err := NewBuilder(db).Tenant(int64(1)).Table("person").LeftJoin("orders", "o", "o.person_id = person.id").Pluck("o.amount", &amounts); err != nil {
+		t.Fatal(err)
+	}
+	if len(amounts) != 1 || amounts[0] != 10 {
+		t.Errorf("unexpected amounts: %v", amounts)
+	}
+	if _, _, err := NewBuilder(db).Table("person").Join("orders", "orders.person_id = person.id").BuildQuery(); err != ErrTenantRequired {
+		t.Errorf("expected ErrTenantRequired, got %v", err)
+	}
+	if _, _, err := NewBuilder(db).Tenant(int64(1)).Table("person").Join("orders").BuildQuery(); err != ErrTenantJoin {
+		t.Errorf("expected ErrTenantJoin, got %v", err)
+	}
+	if n, err := NewBuilder(db).CrossTenant().Table("person").Join("orders", "orders.person_id = person.id").Count(); err != nil || n != 2 {
+		t.Errorf("expected 2 rows across tenants, got %d %v", n, err)
+	}
+}
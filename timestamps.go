@@ -0,0 +1,147 @@
+package builder
+
+import (
+	"reflect"
+	"time"
+)
+
+var (
+	// NowFunc 全局时钟函数，用于填充时间字段，测试时可替换
+	NowFunc = time.Now
+	// CreatedColumns 约定的创建时间字段，模型启用AutoTimestamps时数据中存在且为零值则自动填充
+	CreatedColumns = []string{"created_at", "create_at"}
+	// UpdatedColumns 约定的更新时间字段，模型启用AutoTimestamps时数据中存在则自动填充
+	UpdatedColumns = []string{"updated_at", "update_at"}
+)
+
+// Clock 设置当前builder的时钟函数
+func (b *Builder) Clock(f func() time.Time) *Builder {
+	b.clock = f
+	return b
+}
+
+// now 返回当前时间
+func (b *Builder) now() time.Time {
+	if b.clock != nil {
+		return b.clock()
+	}
+	return NowFunc()
+}
+
+// timestampColumns 返回当前表的创建时间及更新时间字段，declared表示字段由模型声明；
+// 未注册模型或模型未启用时不维护时间字段
+func (b *Builder) timestampColumns() (created, updated []string, declared bool) {
+	m := b.model()
+	switch {
+	case m == nil:
+		return nil, nil, false
+	case m.CreatedAt != "" || m.UpdatedAt != "":
+		if m.CreatedAt != "" {
+			created = append(created, m.CreatedAt)
+		}
+		if m.UpdatedAt != "" {
+			updated = append(updated, m.UpdatedAt)
+		}
+		return created, updated, true
+	case m.AutoTimestamps:
+		return CreatedColumns, UpdatedColumns, false
+	}
+	return nil, nil, false
+}
+
+// touchTimestamps 按执行方法填充时间字段，同时回写到struct
+func (b *Builder) touchTimestamps(method string, s interface{}, data map[string]interface{}) {
+	// 模型声明的字段总是填充，约定字段仅在数据中存在时填充
+	created, updated, declared := b.timestampColumns()
+	if len(created) == 0 && len(updated) == 0 {
+		return
+	}
+	now := b.now()
+	switch method {
+	case "INSERT", "INSERT_IGNORE", "INSERT_ON_DUPLICATE_UPDATE":
+		for _, column := range created {
+			v, ok := data[column]
+			if (ok || declared) && isZero(v) {
+				data[column] = timestampValue(v, now)
+				setField(s, column, data[column])
+			}
+		}
+	case "UPDATE":
+		// 避免以零值覆盖创建时间
+		for _, column := range created {
+			if v, ok := data[column]; ok && isZero(v) {
+				delete(data, column)
+			}
+		}
+	default:
+		return
+	}
+	for _, column := range updated {
+		v, ok := data[column]
+		if ok || declared {
+			data[column] = timestampValue(v, now)
+			setField(s, column, data[column])
+		}
+	}
+}
+
+// withoutCreated 返回去除创建时间字段的数据，用于upsert的更新部分
+func (b *Builder) withoutCreated(data map[string]interface{}) map[string]interface{} {
+	created, _, _ := b.timestampColumns()
+	if len(created) == 0 {
+		return data
+	}
+	result := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		if indexOf(k, created) == -1 {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// timestampValue 按原字段类型转换时间
+func timestampValue(old interface{}, t time.Time) interface{} {
+	switch old.(type) {
+	case string:
+		return t.Format(TimeFormat)
+	case int64:
+		return t.Unix()
+	case int:
+		return int(t.Unix())
+	case *time.Time:
+		return &t
+	default:
+		return t
+	}
+}
+
+// setField 将值回写到struct指针对应字段
+func setField(s interface{}, column string, v interface{}) {
+	if s == nil {
+		return
+	}
+	rv := reflect.ValueOf(s)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return
+	}
+	rv = rv.Elem()
+	for _, f := range structFields(rv.Type()) {
+		if f.column != column {
+			continue
+		}
+		field := rv.FieldByIndex(f.field.Index)
+		value := reflect.ValueOf(v)
+		if field.CanSet() && value.IsValid() && value.Type().AssignableTo(field.Type()) {
+			field.Set(value)
+		}
+		return
+	}
+}
+
+func isZero(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	return reflect.ValueOf(v).IsZero()
+}
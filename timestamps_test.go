@@ -0,0 +1,112 @@
+package builder
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type testArticle struct {
+	ID       int64  `db:"id" json:"id"`
+	Title    string `db:"title" json:"title"`
+	CreateAt string `db:"create_at" json:"create_at" sqlxb:"created"`
+	UpdateAt string `db:"update_at" json:"update_at" sqlxb:"updated"`
+}
+
+func TestTimestamps(t *testing.T) {
+	RegisterModel("article", FromStruct(testArticle{}))
+	defer UnregisterModel("article")
+	db := newTestDB(t, "CREATE TABLE article (id INTEGER PRIMARY KEY, title TEXT, create_at TEXT, update_at TEXT)")
+	clock := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	a := &testArticle{ID: 1, Title: "a"}
+	if _, err := NewBuilder(db).Clock(func() time.Time { return clock }).Table("article").Insert(a); err != nil {
+		t.Fatal(err)
+	}
+	if a.CreateAt != "2020-01-02 03:04:05" || a.UpdateAt != a.CreateAt {
+		t.Errorf("timestamps not filled: %+v", a)
+	}
+
+	later := clock.Add(time.Hour)
+	if _, err := NewBuilder(db).Clock(func() time.Time { return later }).Table("article").Where("id = ?", 1).Update(&testArticle{ID: 1, Title: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	got := &testArticle{}
+	if err := NewBuilder(db).Table("article").Where("id = ?", 1).One(got); err != nil {
+		t.Fatal(err)
+	}
+	if got.CreateAt != "2020-01-02 03:04:05" || got.UpdateAt != "2020-01-02 04:04:05" {
+		t.Errorf("unexpected timestamps after update: %+v", got)
+	}
+
+	rows := []testArticle{{ID: 2, Title: "c"}, {ID: 3, Title: "d"}}
+	if _, err := NewBuilder(db).Clock(func() time.Time { return clock }).Table("article").BatchInsert(rows); err != nil {
+		t.Fatal(err)
+	}
+	if rows[1].CreateAt != "2020-01-02 03:04:05" {
+		t.Errorf("batch insert timestamps not filled: %+v", rows)
+	}
+}
+
+func TestUpsertKeepsCreated(t *testing.T) {
+	RegisterModel("article", Timestamps("create_at", "update_at"))
+	defer UnregisterModel("article")
+	b := NewBuilder(nil).Table("article")
+	data := map[string]interface{}{"title": "a"}
+	b.touchTimestamps("INSERT_ON_DUPLICATE_UPDATE", nil, data)
+	query, _, _ := b.BuildExec("INSERT_ON_DUPLICATE_UPDATE", data)
+	update := query[strings.Index(query, "UPDATE"):]
+	if strings.Contains(update, "create_at") || !strings.Contains(update, "update_at") {
+		t.Errorf("unexpected upsert: %s", query)
+	}
+}
+
+func TestTimestampsOptIn(t *testing.T) {
+	db := newTestDB(t, "CREATE TABLE note (id INTEGER PRIMARY KEY, title TEXT, updated_at TEXT)")
+	data := map[string]interface{}{"id": 1, "title": "a", "updated_at": "2000-01-01 00:00:00"}
+	if _, err := NewBuilder(db).Table("note").Insert(data); err != nil {
+		t.Fatal(err)
+	}
+	var updated string
+	if err := NewBuilder(db).Table("note").Fields("updated_at").Where("id = ?", 1).One(&updated); err != nil {
+		t.Fatal(err)
+	}
+	if updated != "2000-01-01 00:00:00" {
+		t.Errorf("unregistered table should keep caller's value, got %s", updated)
+	}
+
+	RegisterModel("note", AutoTimestamps())
+	defer UnregisterModel("note")
+	clock := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if _, err := NewBuilder(db).Clock(func() time.Time { return clock }).Table("note").Where("id = ?", 1).Update(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewBuilder(db).Table("note").Fields("updated_at").Where("id = ?", 1).One(&updated); err != nil {
+		t.Fatal(err)
+	}
+	if updated != "2020-01-02 03:04:05" {
+		t.Errorf("convention column not filled: %s", updated)
+	}
+}
+
+func TestBatchInsertBindsValues(t *testing.T) {
+	db := newTestDB(t, "CREATE TABLE article (id INTEGER PRIMARY KEY, title TEXT, create_at TEXT, update_at TEXT)")
+	rows := []testArticle{{ID: 1, Title: "O'Brien"}, {ID: 2, Title: "x'); DROP TABLE article; --"}}
+	query, args, err := NewBuilder(db).Table("article").BuildBatchInsert([]map[string]interface{}{toMap(&rows[0]), toMap(&rows[1])})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(query, "O'Brien") || len(args) != 8 {
+		t.Errorf("values should be bound as args: %s %v", query, args)
+	}
+	if _, err := NewBuilder(db).Table("article").BatchInsert(rows); err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	if err := NewBuilder(db).Table("article").Fields("title").OrderBy("id").All(&titles); err != nil {
+		t.Fatal(err)
+	}
+	if len(titles) != 2 || titles[0] != "O'Brien" {
+		t.Errorf("unexpected titles: %v", titles)
+	}
+}
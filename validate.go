@@ -0,0 +1,73 @@
+package builder
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DefaultValidate 新建builder时是否默认开启写入校验
+var DefaultValidate = false
+
+// Validator 数据校验接口，兼容protoc-gen-govalidators生成的Validate方法
+type Validator interface {
+	Validate() error
+}
+
+// ValidatorFunc 自定义校验函数
+type ValidatorFunc func(data interface{}) error
+
+// ValidationError 数据校验错误，Index为批量写入时出错记录的下标
+type ValidationError struct {
+	Index int
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validation failed at record %d: %v", e.Index, e.Err)
+}
+
+// Unwrap 返回原始校验错误
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+var validators = struct {
+	sync.RWMutex
+	fs []ValidatorFunc
+}{}
+
+// RegisterValidator 注册全局校验函数，开启校验后对每条写入数据调用
+func RegisterValidator(f ValidatorFunc) {
+	validators.Lock()
+	validators.fs = append(validators.fs, f)
+	validators.Unlock()
+}
+
+// Validation 设置写入(Insert/Update/Upsert/BatchInsert)前是否校验数据
+func (b *Builder) Validation(v bool) *Builder {
+	b.validate = v
+	return b
+}
+
+// beforeWrite 写入前调用钩子并校验数据
+func (b *Builder) beforeWrite(method string, index int, s interface{}) error {
+	if err := callBefore(method, s); err != nil {
+		return err
+	}
+	if !b.validate || method == "DELETE" {
+		return nil
+	}
+	if v, ok := s.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return &ValidationError{Index: index, Err: err}
+		}
+	}
+	validators.RLock()
+	defer validators.RUnlock()
+	for _, f := range validators.fs {
+		if err := f(s); err != nil {
+			return &ValidationError{Index: index, Err: err}
+		}
+	}
+	return nil
+}
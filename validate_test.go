@@ -0,0 +1,43 @@
+package builder
+
+import (
+	"errors"
+	"testing"
+)
+
+type testItem struct {
+	ID    int64 `db:"id" json:"id"`
+	Price int64 `db:"price" json:"price"`
+}
+
+func (i *testItem) Validate() error {
+	if i.Price < 0 {
+		return errors.New("price must be positive")
+	}
+	return nil
+}
+
+func TestValidation(t *testing.T) {
+	db := newTestDB(t, "CREATE TABLE item (id INTEGER PRIMARY KEY, price INTEGER)")
+	if _, err := NewBuilder(db).Table("item").Insert(&testItem{ID: 1, Price: -1}); err != nil {
+		t.Fatal("validation should be opt-in:", err)
+	}
+
+	_, err := NewBuilder(db).Validation(true).Table("item").BatchInsert([]*testItem{{ID: 2, Price: 1}, {ID: 3, Price: -1}})
+	verr, ok := err.(*ValidationError)
+	if !ok || verr.Index != 1 {
+		t.Fatalf("expected validation error at record 1, got %v", err)
+	}
+
+	RegisterValidator(func(data interface{}) error {
+		if m, ok := data.(map[string]interface{}); ok && m["price"] == 0 {
+			return errors.New("price is required")
+		}
+		return nil
+	})
+	defer func() { validators.fs = nil }()
+	_, err = NewBuilder(db).Validation(true).Table("item").Where("id = ?", 1).Update(map[string]interface{}{"price": 0})
+	if _, ok := err.(*ValidationError); !ok {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}